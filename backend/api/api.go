@@ -9,6 +9,7 @@ import (
 	"goaway/backend/api/key"
 	"goaway/backend/api/ratelimit"
 	"goaway/backend/blacklist"
+	"goaway/backend/certificate"
 	"goaway/backend/dns/server"
 	"goaway/backend/logging"
 	"goaway/backend/notification"
@@ -16,6 +17,7 @@ import (
 	"goaway/backend/request"
 	"goaway/backend/resolution"
 	"goaway/backend/settings"
+	"goaway/backend/syslog"
 	"goaway/backend/user"
 	"goaway/backend/whitelist"
 	"io/fs"
@@ -68,6 +70,8 @@ type API struct {
 	NotificationService *notification.Service
 	BlacklistService    *blacklist.Service
 	WhitelistService    *whitelist.Service
+	Certificates        *certificate.Service
+	Syslog              *syslog.Forwarder
 
 	server         *http.Server
 	IsShuttingDown bool
@@ -134,8 +138,9 @@ func (api *API) initializeRouter() {
 	gin.SetMode(gin.ReleaseMode)
 	api.router = gin.New()
 
-	// Ignore compression on this route as otherwise it has problems with exposing the Content-Length header
-	ignoreCompression := gzip.WithExcludedPaths([]string{"/api/exportDatabase"})
+	// Ignore compression on these routes, the database export has problems exposing the Content-Length header
+	// and query log exports are streamed, compressed only when asked for
+	ignoreCompression := gzip.WithExcludedPaths([]string{"/api/exportDatabase", "/api/queries/export"})
 	api.router.Use(gzip.Gzip(gzip.DefaultCompression, ignoreCompression))
 	api.routes = api.router.Group("/api")
 }
@@ -174,10 +179,14 @@ func (api *API) setupRoutes() {
 	api.registerDNSRoutes()
 	api.registerUpstreamRoutes()
 	api.registerListsRoutes()
+	api.registerBundleRoutes()
 	api.registerResolutionRoutes()
+	api.registerZoneRoutes()
 	api.registerSettingsRoutes()
 	api.registerNotificationRoutes()
 	api.registerAlertRoutes()
+	api.registerCertificateRoutes()
+	api.registerMetricsRoutes()
 }
 
 func (api *API) setupAuthAndMiddleware() {
@@ -19,6 +19,7 @@ func (api *API) registerBlacklistRoutes() {
 	api.routes.GET("/domains", api.getBlacklistedDomains)
 	api.routes.GET("/topBlockedDomains", api.getTopBlockedDomains)
 	api.routes.GET("/getDomainsForList", api.getDomainsForList)
+	api.routes.GET("/explainDomain", api.explainDomain)
 	api.routes.DELETE("/blacklist", api.removeDomainFromCustom)
 	api.routes.DELETE("/prefetch", api.deletePrefetchedDomain)
 }
@@ -140,6 +141,22 @@ func (api *API) getDomainsForList(c *gin.Context) {
 	c.JSON(http.StatusOK, domains)
 }
 
+func (api *API) explainDomain(c *gin.Context) {
+	domain := c.Query("domain")
+	if domain == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'domain' query parameter"})
+		return
+	}
+
+	explanation, err := api.DNSServer.ExplainDomain(context.Background(), domain, c.Query("client"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
 func (api *API) deletePrefetchedDomain(c *gin.Context) {
 	domainPrefetchToDelete := c.Query("domain")
 
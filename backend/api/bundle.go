@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"goaway/backend/audit"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerBundleRoutes() {
+	api.routes.GET("/bundles", api.getBundles)
+	api.routes.POST("/bundle", api.updateBundleStatus)
+}
+
+func (api *API) getBundles(c *gin.Context) {
+	bundles, err := api.DNSServer.BundleService.GetBundles(context.Background())
+	if err != nil {
+		log.Error("Failed to fetch service bundles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch service bundles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundles)
+}
+
+func (api *API) updateBundleStatus(c *gin.Context) {
+	var request struct {
+		ID      string `json:"id"`
+		Blocked bool   `json:"blocked"`
+	}
+	if err := c.BindJSON(&request); err != nil || request.ID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	bundle, err := api.DNSServer.BundleService.SetBlocked(context.Background(), request.ID, request.Blocked)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action := "unblocked"
+	if request.Blocked {
+		action = "blocked"
+	}
+	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
+		Topic:   audit.TopicList,
+		Message: fmt.Sprintf("Service '%s' was %s", bundle.Name, action),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%s has been %s.", bundle.Name, action)})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"errors"
+	"goaway/backend/certificate"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerCertificateRoutes() {
+	api.routes.GET("/certificate/ca", api.getCACertificate)
+}
+
+// getCACertificate downloads the local CA the generated DoT/DoH certificate is issued by, for clients to trust it.
+func (api *API) getCACertificate(c *gin.Context) {
+	data, err := api.Certificates.CACertificate()
+	if errors.Is(err, certificate.ErrNoLocalCA) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="goaway-ca.pem"`)
+	c.Data(http.StatusOK, "application/x-pem-file", data)
+}
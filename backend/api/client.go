@@ -16,6 +16,8 @@ func (api *API) registerClientRoutes() {
 
 	api.routes.PUT("/client/:ip/name/:name", api.updateClientName)
 	api.routes.PUT("/client/:ip/bypass/:bypass", api.updateClientBypass)
+
+	api.routes.POST("/reloadVendors", api.reloadVendors)
 }
 
 func (api *API) getClients(c *gin.Context) {
@@ -133,3 +135,19 @@ func (api *API) updateClientBypass(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+func (api *API) reloadVendors(c *gin.Context) {
+	path := api.Config.Clients.VendorRegistry
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no vendor registry file is configured"})
+		return
+	}
+
+	assignments, err := api.DNSServer.MACService.LoadRegistryFile(path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"goaway/backend/api/models"
 	"goaway/backend/audit"
 	"goaway/backend/database"
 	"goaway/backend/dns/server"
@@ -10,7 +9,6 @@ import (
 	"goaway/backend/settings"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,9 +22,13 @@ func (api *API) registerDNSRoutes() {
 	api.routes.POST("/pause", api.pauseBlocking)
 	api.routes.GET("/pause", api.getBlocking)
 	api.routes.GET("/queries", api.getQueries)
+	api.routes.GET("/queries/export", api.exportQueries)
 	api.routes.GET("/queryTimestamps", api.getQueryTimestamps)
 	api.routes.GET("/responseSizeTimestamps", api.getResponseSizeTimestamps)
 	api.routes.GET("/queryTypes", api.getQueryTypes)
+	api.routes.GET("/deniedQueries", api.getDeniedQueries)
+	api.routes.GET("/rateLimited", api.getRateLimited)
+	api.routes.GET("/logQueue", api.getLogQueue)
 
 	api.routes.DELETE("/queries", api.clearQueries)
 	api.routes.DELETE("/pause", api.clearBlocking)
@@ -84,7 +86,11 @@ func (api *API) getBlocking(c *gin.Context) {
 }
 
 func (api *API) getQueries(c *gin.Context) {
-	query := parseQueryParams(c)
+	query, err := parseQueryParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	type result struct {
 		err     error
@@ -101,7 +107,7 @@ func (api *API) getQueries(c *gin.Context) {
 	}()
 
 	go func() {
-		total, err := api.RequestService.CountQueries(query.Search)
+		total, err := api.RequestService.CountQueries(query.QueryFilter)
 		countCh <- result{total: total, err: err}
 	}()
 
@@ -118,56 +124,20 @@ func (api *API) getQueries(c *gin.Context) {
 		return
 	}
 
+	var nextCursor string
+	if queries := queryResult.queries; query.PageSize > 0 && len(queries) == query.PageSize && query.Column != "ip" {
+		nextCursor = strconv.FormatUint(uint64(queries[len(queries)-1].ID), 10)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"draw":            c.DefaultQuery("draw", "1"),
 		"recordsTotal":    countResult.total,
 		"recordsFiltered": countResult.total,
 		"queries":         queryResult.queries,
+		"nextCursor":      nextCursor,
 	})
 }
 
-func parseQueryParams(c *gin.Context) models.QueryParams {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
-	search := c.DefaultQuery("search", "")
-	sortColumn := c.DefaultQuery("sortColumn", "timestamp")
-	sortDirection := c.DefaultQuery("sortDirection", "desc")
-	filterClient := c.DefaultQuery("client", "")
-
-	validColumns := map[string]string{
-		"timestamp":         "timestamp",
-		"domain":            "domain",
-		"client":            "client_ip",
-		"ip":                "ip",
-		"status":            "status",
-		"responseTimeNS":    "response_time_ns",
-		"queryType":         "query_type",
-		"blocked":           "blocked",
-		"cached":            "cached",
-		"responseSizeBytes": "response_size_bytes",
-	}
-
-	column, ok := validColumns[sortColumn]
-	if !ok {
-		column = "timestamp"
-	}
-
-	sortDirection = strings.ToLower(sortDirection)
-	if sortDirection != "asc" && sortDirection != "desc" {
-		sortDirection = "desc"
-	}
-
-	return models.QueryParams{
-		Page:         page,
-		PageSize:     pageSize,
-		Search:       search,
-		Column:       column,
-		Direction:    strings.ToUpper(sortDirection),
-		Offset:       (page - 1) * pageSize,
-		FilterClient: filterClient,
-	}
-}
-
 func (api *API) getQueryTimestamps(c *gin.Context) {
 	intervalParam := c.Query("interval")
 	if intervalParam == "" {
@@ -220,6 +190,26 @@ func (api *API) getQueryTypes(c *gin.Context) {
 	c.JSON(http.StatusOK, queries)
 }
 
+func (api *API) getDeniedQueries(c *gin.Context) {
+	total, clients := api.DNSServer.DeniedQueries()
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"clients": clients,
+	})
+}
+
+func (api *API) getLogQueue(c *gin.Context) {
+	c.JSON(http.StatusOK, api.DNSServer.LogQueueStats())
+}
+
+func (api *API) getRateLimited(c *gin.Context) {
+	queries, responses := api.DNSServer.RateLimited()
+	c.JSON(http.StatusOK, gin.H{
+		"queries":   queries,
+		"responses": responses,
+	})
+}
+
 func (api *API) clearQueries(c *gin.Context) {
 	if err := api.DBConn.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&database.RequestLog{}).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not clear query logs", "reason": err.Error()})
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"goaway/backend/alert"
 	"goaway/backend/audit"
+	"goaway/backend/metrics"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,12 +19,15 @@ func (api *API) registerListsRoutes() {
 	api.routes.POST("/custom", api.updateCustom)
 	api.routes.POST("/addList", api.addList)
 	api.routes.POST("/addLists", api.addLists)
+	api.routes.POST("/rollbackList", api.rollbackList)
 
 	api.routes.GET("/lists", api.getLists)
 	api.routes.GET("/fetchUpdatedList", api.fetchUpdatedList)
 	api.routes.GET("/runUpdateList", api.runUpdateList)
 	api.routes.GET("/toggleBlocklist", api.toggleBlocklist)
 	api.routes.GET("/updateBlockStatus", api.handleUpdateBlockStatus)
+	api.routes.GET("/listRevisions", api.getListRevisions)
+	api.routes.GET("/listRevision", api.getListRevision)
 
 	api.routes.PATCH("/listName", api.updateListName)
 
@@ -261,16 +266,23 @@ func (api *API) runUpdateList(c *gin.Context) {
 		return
 	}
 
+	if err := api.BlacklistService.SnapshotSource(context.Background(), name, listURL); err != nil {
+		log.Warning("Failed to snapshot list '%s' before updating: %v", name, err)
+	}
+
 	err := api.BlacklistService.RemoveSourceAndDomains(context.Background(), name, listURL)
 	if err != nil {
+		metrics.ObserveListUpdate(name, metrics.ListFailed)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	err = api.BlacklistService.FetchAndLoadHosts(context.Background(), listURL, name)
 	if err != nil {
+		metrics.ObserveListUpdate(name, metrics.ListFailed)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	metrics.ObserveListUpdate(name, metrics.ListUpdated)
 
 	go func() {
 		_ = api.DNSServer.AlertService.SendToAll(context.Background(), alert.Message{
@@ -353,6 +365,10 @@ func (api *API) removeList(c *gin.Context) {
 		return
 	}
 
+	if err := api.BlacklistService.RemoveRevisions(context.Background(), name, listURL); err != nil {
+		log.Warning("Failed to remove revisions for list '%s': %v", name, err)
+	}
+
 	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
 		Topic:   audit.TopicList,
 		Message: fmt.Sprintf("Blacklist with name '%s' was deleted", name),
@@ -360,6 +376,73 @@ func (api *API) removeList(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func (api *API) getListRevisions(c *gin.Context) {
+	name := c.Query("name")
+	listURL := c.Query("url")
+
+	if name == "" || listURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing 'name' or 'url' query parameter"})
+		return
+	}
+
+	revisions, err := api.BlacklistService.GetRevisions(context.Background(), name, listURL)
+	if err != nil {
+		log.Error("Failed to fetch revisions for list '%s': %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (api *API) getListRevision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision id"})
+		return
+	}
+
+	diff, err := api.BlacklistService.GetRevisionDiff(context.Background(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (api *API) rollbackList(c *gin.Context) {
+	var request struct {
+		ID uint `json:"id"`
+	}
+	if err := c.BindJSON(&request); err != nil || request.ID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	revision, err := api.BlacklistService.RollbackSource(context.Background(), request.ID)
+	if err != nil {
+		log.Error("Failed to roll back list: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
+		Topic:   audit.TopicList,
+		Message: fmt.Sprintf("Blacklist '%s' was rolled back to revision %d", revision.SourceName, revision.ID),
+	})
+
+	go func() {
+		_ = api.DNSServer.AlertService.SendToAll(context.Background(), alert.Message{
+			Title:    "System",
+			Content:  fmt.Sprintf("List '%s' was rolled back to the version from %s", revision.SourceName, revision.CreatedAt.Format("2006-01-02 15:04")),
+			Severity: SeveritySuccess,
+		})
+	}()
+
+	c.JSON(http.StatusOK, revision)
+}
+
 func (api *API) validateURLAndName(listURL, name string) error {
 	if name == "" || listURL == "" {
 		return fmt.Errorf("name and URL are required")
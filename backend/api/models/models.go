@@ -2,14 +2,41 @@ package models
 
 import "time"
 
+// QueryFilter narrows down the query log, fields left empty don't filter.
+type QueryFilter struct {
+	// Domain is matched as a substring, unless ExactDomain is set
+	Domain      string
+	ExactDomain bool
+
+	// Client is matched as a substring of the client IP or name
+	Client string
+
+	From time.Time
+	To   time.Time
+
+	Statuses   []string
+	QueryTypes []string
+	Protocols  []string
+	Blocked    *bool
+	Cached     *bool
+
+	MinResponseTime time.Duration
+	MaxResponseTime time.Duration
+
+	// ResolvedIP matches queries answered with this address
+	ResolvedIP string
+}
+
 type QueryParams struct {
-	Search       string
-	Column       string
-	Direction    string
-	FilterClient string
-	Page         int
-	PageSize     int
-	Offset       int
+	QueryFilter
+	Column    string
+	Direction string
+	Page      int
+	PageSize  int
+	Offset    int
+
+	// Cursor is the ID of the last query of the previous page, used instead of Offset when set
+	Cursor uint
 }
 
 type DomainHistory struct {
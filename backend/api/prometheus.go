@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"goaway/backend/metrics"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	cacheRecordsDesc = prometheus.NewDesc(
+		"goaway_dns_cache_records", "Records in the DNS cache.", nil, nil,
+	)
+	logQueueDepthDesc = prometheus.NewDesc(
+		"goaway_query_log_queue_depth", "Query log entries waiting to be saved.", nil, nil,
+	)
+	logQueueCapacityDesc = prometheus.NewDesc(
+		"goaway_query_log_queue_capacity", "Query log entries that can wait to be saved.", nil, nil,
+	)
+	logQueueDroppedDesc = prometheus.NewDesc(
+		"goaway_query_log_dropped_total", "Query log entries dropped while the queue was full.", nil, nil,
+	)
+	logQueueSpilledDesc = prometheus.NewDesc(
+		"goaway_query_log_spilled_total", "Query log entries written to disk while the queue was full.", nil, nil,
+	)
+	blocklistDomainsDesc = prometheus.NewDesc(
+		"goaway_blocklist_domains", "Domains blocked by a list.", []string{"list", "active"}, nil,
+	)
+	blocklistUpdatedDesc = prometheus.NewDesc(
+		"goaway_blocklist_last_updated_timestamp_seconds", "Time a list was last updated.", []string{"list"}, nil,
+	)
+)
+
+// stateCollector reads the current state of the server each time metrics are scraped.
+type stateCollector struct {
+	api *API
+}
+
+func (s *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheRecordsDesc
+	ch <- logQueueDepthDesc
+	ch <- logQueueCapacityDesc
+	ch <- logQueueDroppedDesc
+	ch <- logQueueSpilledDesc
+	ch <- blocklistDomainsDesc
+	ch <- blocklistUpdatedDesc
+}
+
+func (s *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	var records int
+	s.api.DNSServer.DomainCache.Range(func(_, _ any) bool {
+		records++
+		return true
+	})
+	ch <- prometheus.MustNewConstMetric(cacheRecordsDesc, prometheus.GaugeValue, float64(records))
+
+	stats := s.api.DNSServer.LogQueueStats()
+	ch <- prometheus.MustNewConstMetric(logQueueDepthDesc, prometheus.GaugeValue, float64(stats.Depth))
+	ch <- prometheus.MustNewConstMetric(logQueueCapacityDesc, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(logQueueDroppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(logQueueSpilledDesc, prometheus.CounterValue, float64(stats.Spilled))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lists, err := s.api.BlacklistService.GetAllListStatistics(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(blocklistDomainsDesc, err)
+		return
+	}
+	for _, list := range lists {
+		ch <- prometheus.MustNewConstMetric(
+			blocklistDomainsDesc, prometheus.GaugeValue, float64(list.BlockedCount), list.Name, strconv.FormatBool(list.Active),
+		)
+		if !list.LastUpdated.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				blocklistUpdatedDesc, prometheus.GaugeValue, float64(list.LastUpdated.Unix()), list.Name,
+			)
+		}
+	}
+}
+
+func (api *API) registerMetricsRoutes() {
+	// Responses are compressed by the router
+	handler := promhttp.HandlerFor(
+		metrics.NewRegistry(&stateCollector{api: api}),
+		promhttp.HandlerOpts{DisableCompression: true},
+	)
+
+	api.router.GET("/metrics", func(c *gin.Context) {
+		config := api.Config.API.Metrics
+		if !config.Enabled {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Metrics are disabled"})
+			return
+		}
+		if key := metricsKey(c); config.RequireKey && (key == "" || !api.KeyService.VerifyKey(key)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing API key"})
+			return
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// metricsKey returns the API key of a request, which Prometheus sends as a bearer token.
+func metricsKey(c *gin.Context) string {
+	if key := c.GetHeader("api-key"); key != "" {
+		return key
+	}
+	key, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return key
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rows written between flushes of an export
+const exportFlushInterval = 1000
+
+var exportCSVHeader = []string{
+	"timestamp", "domain", "client_ip", "client_name", "query_type", "status", "protocol",
+	"blocked", "cached", "response_time_ns", "response_size_bytes", "resolved",
+}
+
+// queryEncoder writes queries in an export format.
+type queryEncoder interface {
+	encode(query model.RequestLogEntry) error
+	flush() error
+}
+
+type csvQueryEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVQueryEncoder(w io.Writer) (*csvQueryEncoder, error) {
+	encoder := &csvQueryEncoder{writer: csv.NewWriter(w)}
+	return encoder, encoder.writer.Write(exportCSVHeader)
+}
+
+func (e *csvQueryEncoder) encode(query model.RequestLogEntry) error {
+	resolved := make([]string, len(query.IP))
+	for i, ip := range query.IP {
+		resolved[i] = ip.IP.String()
+	}
+
+	var clientIP, clientName string
+	if query.ClientInfo != nil {
+		clientIP, clientName = query.ClientInfo.IP.String(), query.ClientInfo.Name
+	}
+
+	return e.writer.Write([]string{
+		query.Timestamp.UTC().Format(time.RFC3339Nano),
+		csvSafe(query.Domain),
+		clientIP,
+		csvSafe(clientName),
+		query.QueryType,
+		query.Status,
+		string(query.Protocol),
+		strconv.FormatBool(query.Blocked),
+		strconv.FormatBool(query.Cached),
+		strconv.FormatInt(query.ResponseTime.Nanoseconds(), 10),
+		strconv.Itoa(query.ResponseSizeBytes),
+		strings.Join(resolved, " "),
+	})
+}
+
+func (e *csvQueryEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// csvSafe keeps spreadsheets from evaluating values as formulas, as domains and client names are chosen by
+// whoever sends the query.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+type ndjsonQueryEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonQueryEncoder) encode(query model.RequestLogEntry) error {
+	return e.encoder.Encode(query)
+}
+
+func (e *ndjsonQueryEncoder) flush() error {
+	return nil
+}
+
+func (api *API) exportQueries(c *gin.Context) {
+	filter, err := parseQueryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = "text/csv"
+	case "ndjson":
+		contentType = "application/x-ndjson"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown format '%s', expected csv or ndjson", format)})
+		return
+	}
+
+	compress, err := parseQueryFlag(c, "gzip")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "queries." + format
+	if compress != nil && *compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	buffered := bufio.NewWriter(c.Writer)
+	var w io.Writer = buffered
+	var zw *gzip.Writer
+	if compress != nil && *compress {
+		zw = gzip.NewWriter(buffered)
+		w = zw
+	}
+
+	var encoder queryEncoder
+	if format == "csv" {
+		encoder, err = newCSVQueryEncoder(w)
+	} else {
+		encoder = &ndjsonQueryEncoder{encoder: json.NewEncoder(w)}
+	}
+
+	flush := func() error {
+		if err := encoder.flush(); err != nil {
+			return err
+		}
+		if zw != nil {
+			if err := zw.Flush(); err != nil {
+				return err
+			}
+		}
+		if err := buffered.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	var rows int
+	if err == nil {
+		err = api.RequestService.StreamQueries(filter, func(query model.RequestLogEntry) error {
+			if err := encoder.encode(query); err != nil {
+				return err
+			}
+			rows++
+			if rows%exportFlushInterval == 0 {
+				return flush()
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err == nil && zw != nil {
+		if err = zw.Close(); err == nil {
+			err = buffered.Flush()
+		}
+	}
+
+	// The status was already sent, so a failed export can only be cut short
+	if err != nil {
+		log.Warning("Query log export failed after %d row(s): %v", rows, err)
+		return
+	}
+	log.Debug("Exported %d queries as %s", rows, filename)
+}
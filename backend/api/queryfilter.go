@@ -0,0 +1,168 @@
+package api
+
+import (
+	"fmt"
+	"goaway/backend/api/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maps the sort columns of the API to request log columns
+var querySortColumns = map[string]string{
+	"timestamp":         "timestamp",
+	"domain":            "domain",
+	"client":            "client_ip",
+	"ip":                "ip",
+	"status":            "status",
+	"protocol":          "protocol",
+	"responseTimeNS":    "response_time_ns",
+	"queryType":         "query_type",
+	"blocked":           "blocked",
+	"cached":            "cached",
+	"responseSizeBytes": "response_size_bytes",
+}
+
+func parseQueryParams(c *gin.Context) (models.QueryParams, error) {
+	filter, err := parseQueryFilter(c)
+	if err != nil {
+		return models.QueryParams{}, err
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	page, pageSize = max(page, 1), max(pageSize, 0)
+
+	column, ok := querySortColumns[c.DefaultQuery("sortColumn", "timestamp")]
+	if !ok {
+		column = "timestamp"
+	}
+
+	sortDirection := strings.ToLower(c.DefaultQuery("sortDirection", "desc"))
+	if sortDirection != "asc" && sortDirection != "desc" {
+		sortDirection = "desc"
+	}
+
+	var cursor uint64
+	if value := c.Query("cursor"); value != "" {
+		if cursor, err = strconv.ParseUint(value, 10, 0); err != nil {
+			return models.QueryParams{}, fmt.Errorf("invalid cursor '%s'", value)
+		}
+		if column == "ip" {
+			return models.QueryParams{}, fmt.Errorf("cursor can't be used when sorting by ip")
+		}
+	}
+
+	return models.QueryParams{
+		QueryFilter: filter,
+		Page:        page,
+		PageSize:    pageSize,
+		Column:      column,
+		Direction:   strings.ToUpper(sortDirection),
+		Offset:      (page - 1) * pageSize,
+		Cursor:      uint(cursor),
+	}, nil
+}
+
+// parseQueryFilter reads the query log filters of a request:
+//
+//	search           domain substring, or the exact domain when exact=true
+//	client           client IP or name substring
+//	from, to         RFC 3339 time or unix seconds, to is exclusive
+//	status           comma separated response codes, e.g. NOERROR,NXDOMAIN
+//	queryType        comma separated query types, e.g. A,AAAA
+//	protocol         comma separated protocols, e.g. UDP,DoH
+//	blocked, cached  true or false
+//	minResponseTime  duration, e.g. 50ms
+//	maxResponseTime  duration
+//	ip               address the query was answered with
+func parseQueryFilter(c *gin.Context) (models.QueryFilter, error) {
+	filter := models.QueryFilter{
+		Domain:     strings.TrimSpace(c.Query("search")),
+		Client:     strings.TrimSpace(c.Query("client")),
+		Statuses:   splitList(c.Query("status")),
+		QueryTypes: splitList(c.Query("queryType")),
+		Protocols:  splitList(c.Query("protocol")),
+		ResolvedIP: strings.TrimSpace(c.Query("ip")),
+	}
+
+	exact, err := parseQueryFlag(c, "exact")
+	if err != nil {
+		return filter, err
+	}
+	filter.ExactDomain = exact != nil && *exact
+
+	if filter.Blocked, err = parseQueryFlag(c, "blocked"); err != nil {
+		return filter, err
+	}
+	if filter.Cached, err = parseQueryFlag(c, "cached"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = parseQueryTime(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseQueryTime(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.MinResponseTime, err = parseQueryDuration(c, "minResponseTime"); err != nil {
+		return filter, err
+	}
+	if filter.MaxResponseTime, err = parseQueryDuration(c, "maxResponseTime"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseQueryFlag returns nil when the flag is not set.
+func parseQueryFlag(c *gin.Context, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s', expected true or false", name, value)
+	}
+	return &parsed, nil
+}
+
+func parseQueryTime(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s '%s', expected an RFC 3339 time or unix seconds", name, value)
+	}
+	// Timestamps are stored in local time and compared as text, so the offset must match
+	return parsed.Local(), nil
+}
+
+func parseQueryDuration(c *gin.Context, name string) (time.Duration, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s '%s', expected a duration such as 50ms", name, value)
+	}
+	return parsed, nil
+}
+
+func splitList(value string) []string {
+	var values []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
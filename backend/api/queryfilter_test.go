@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseQueryTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	instant := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2026-10-19T12:00:00Z", instant, false},
+		{"2026-10-19T14:00:00+02:00", instant, false},
+		{"2026-10-19T07:00:00-05:00", instant, false},
+		{"1792411200", instant, false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/queries?from="+url.QueryEscape(tt.value), nil)
+
+		got, err := parseQueryTime(c, "from")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseQueryTime(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseQueryTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		// Stored timestamps are compared as text in local time
+		if !got.IsZero() && got.Location() != time.Local {
+			t.Errorf("parseQueryTime(%q) location = %v, want %v", tt.value, got.Location(), time.Local)
+		}
+	}
+}
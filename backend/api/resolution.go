@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"goaway/backend/audit"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,8 +19,12 @@ func (api *API) registerResolutionRoutes() {
 
 func (api *API) createResolution(c *gin.Context) {
 	type NewResolution struct {
-		IP     string
-		Domain string
+		Domain string `json:"domain"`
+		Type   string `json:"type"`
+		Value  string `json:"value"`
+		TTL    uint32 `json:"ttl"`
+		// Kept for clients that only create A/AAAA records
+		IP string `json:"ip"`
 	}
 
 	var newResolution NewResolution
@@ -29,46 +34,68 @@ func (api *API) createResolution(c *gin.Context) {
 		})
 		return
 	}
+	if newResolution.Value == "" {
+		newResolution.Value = newResolution.IP
+	}
 
-	err := api.ResolutionService.CreateResolution(newResolution.IP, newResolution.Domain)
+	record, err := api.ResolutionService.CreateRecord(newResolution.Domain, newResolution.Type, newResolution.Value, newResolution.TTL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	api.DNSServer.RemoveCachedDomain(newResolution.Domain)
+	api.DNSServer.RemoveCachedDomain(record.Domain)
 
 	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
 		Topic:   audit.TopicResolution,
-		Message: fmt.Sprintf("Added new resolution '%s'", newResolution.Domain),
+		Message: fmt.Sprintf("Added new %s resolution '%s'", record.Type, record.Domain),
 	})
-	c.Status(http.StatusOK)
+	c.JSON(http.StatusOK, record)
 }
 
 func (api *API) getResolutions(c *gin.Context) {
-	resolutions, err := api.ResolutionService.GetResolutions()
+	records, err := api.ResolutionService.GetRecords()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, resolutions)
+	c.JSON(http.StatusOK, records)
 }
 
 func (api *API) deleteResolution(c *gin.Context) {
-	domain := c.Query("domain")
-	ip := c.Query("ip")
-
-	rowsAffected, err := api.ResolutionService.DeleteResolution(ip, domain)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	if rowsAffected == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s does not exist", domain)})
-		return
+	var domain string
+	if id := c.Query("id"); id != "" {
+		parsedID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution id"})
+			return
+		}
+
+		record, err := api.ResolutionService.DeleteRecord(uint(parsedID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		domain = record.Domain
+	} else {
+		domain = c.Query("domain")
+		value := c.Query("value")
+		if value == "" {
+			value = c.Query("ip")
+		}
+
+		rowsAffected, err := api.ResolutionService.DeleteResolution(value, domain)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if rowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s does not exist", domain)})
+			return
+		}
 	}
 
 	api.DNSServer.RemoveCachedDomain(domain)
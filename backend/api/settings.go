@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"goaway/backend/audit"
+	"goaway/backend/dns/server"
+	"goaway/backend/dnstap"
 	"goaway/backend/settings"
+	"goaway/backend/syslog"
 	"io"
 	"net/http"
 	"os"
@@ -36,7 +39,40 @@ func (api *API) updateSettings(c *gin.Context) {
 		return
 	}
 
+	if err := server.ValidateAccessControl(updatedSettings.DNS.Access); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := server.ValidateRateLimits(updatedSettings.DNS.RateLimit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := server.ValidateQueryLog(updatedSettings.QueryLog); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := dnstap.Validate(updatedSettings.DNS.Dnstap); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := syslog.Validate(updatedSettings.Syslog); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	api.Config.Update(updatedSettings)
+	if err := api.DNSServer.ReloadAccessControl(); err != nil {
+		log.Warning("Could not apply access control lists: %v", err)
+	}
+	if err := api.DNSServer.ReloadRateLimits(); err != nil {
+		log.Warning("Could not apply rate limits: %v", err)
+	}
+	if err := api.DNSServer.ReloadDnstap(); err != nil {
+		log.Warning("Could not apply dnstap output: %v", err)
+	}
+	if err := api.Syslog.Reload(api.Config.Syslog); err != nil {
+		log.Warning("Could not apply syslog forwarding: %v", err)
+	}
 	settingsJSON, _ := json.MarshalIndent(updatedSettings, "", "  ")
 	log.Debug("%s", string(settingsJSON))
 
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"goaway/backend/audit"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *API) registerZoneRoutes() {
+	api.routes.GET("/zones", api.getZones)
+	api.routes.GET("/zone", api.getZone)
+	api.routes.POST("/zone", api.saveZone)
+	api.routes.POST("/reloadZones", api.reloadZones)
+	api.routes.DELETE("/zone", api.deleteZone)
+}
+
+func (api *API) getZones(c *gin.Context) {
+	c.JSON(http.StatusOK, api.DNSServer.ZoneService.List())
+}
+
+func (api *API) getZone(c *gin.Context) {
+	origin := c.Query("origin")
+
+	content, err := api.DNSServer.ZoneService.GetContent(origin)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"origin": origin, "content": content})
+}
+
+func (api *API) saveZone(c *gin.Context) {
+	var request struct {
+		Origin  string `json:"origin"`
+		Content string `json:"content"`
+	}
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	info, err := api.DNSServer.ZoneService.Save(request.Origin, request.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
+		Topic:   audit.TopicResolution,
+		Message: fmt.Sprintf("Saved zone '%s' with serial %d", info.Origin, info.Serial),
+	})
+	c.JSON(http.StatusOK, info)
+}
+
+func (api *API) reloadZones(c *gin.Context) {
+	err := api.DNSServer.ZoneService.Reload()
+	if err != nil {
+		log.Warning("Zones reloaded with errors: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "zones": api.DNSServer.ZoneService.List()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.DNSServer.ZoneService.List())
+}
+
+func (api *API) deleteZone(c *gin.Context) {
+	origin := c.Query("origin")
+
+	if err := api.DNSServer.ZoneService.Delete(origin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.DNSServer.AuditService.CreateAudit(&audit.Entry{
+		Topic:   audit.TopicResolution,
+		Message: fmt.Sprintf("Removed zone '%s'", origin),
+	})
+	c.Status(http.StatusOK)
+}
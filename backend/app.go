@@ -8,6 +8,8 @@ import (
 	"goaway/backend/api/key"
 	"goaway/backend/audit"
 	"goaway/backend/blacklist"
+	"goaway/backend/bundle"
+	"goaway/backend/dhcp"
 	"goaway/backend/lifecycle"
 	"goaway/backend/logging"
 	"goaway/backend/mac"
@@ -20,6 +22,7 @@ import (
 	"goaway/backend/setup"
 	"goaway/backend/user"
 	"goaway/backend/whitelist"
+	"goaway/backend/zone"
 	"net/http"
 	"sync"
 	"time"
@@ -71,7 +74,7 @@ func (a *Application) RestartApplication() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	wg.Add(4)
+	wg.Add(6)
 	go func() {
 		defer wg.Done()
 		a.services.UDPServer.Shutdown(ctx)
@@ -96,6 +99,18 @@ func (a *Application) RestartApplication() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if a.services.DoH3Server != nil {
+			if err := a.services.DoH3Server.Shutdown(ctx); err != nil {
+				mu.Lock()
+				shutdownErrors = append(shutdownErrors, fmt.Errorf("DoH3 server: %w", err))
+				mu.Unlock()
+			}
+			log.Warning("Stopped DNS-over-HTTP/3 server")
+		}
+	}()
+
 	go func() {
 		defer wg.Done()
 		if a.services.DoTServer != nil {
@@ -104,7 +119,17 @@ func (a *Application) RestartApplication() {
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		if a.services.DoQServer != nil {
+			a.services.DoQServer.Shutdown(ctx)
+			log.Warning("Stopped DNS-over-QUIC server")
+		}
+	}()
+
 	wg.Wait()
+	a.context.DNSServer.CloseDnstap()
+	a.context.Syslog.Close()
 
 	if len(shutdownErrors) > 0 {
 		log.Warning("Shutdown completed with errors:")
@@ -138,25 +163,41 @@ func (a *Application) Start() error {
 	dbConn := a.context.DBConn
 	alertService := alert.NewService(alert.NewRepository(dbConn))
 	auditService := audit.NewService(audit.NewRepository(dbConn))
+	auditService.Syslog = a.context.Syslog
 	blacklistService := blacklist.NewService(blacklist.NewRepository(dbConn))
+	bundleService := bundle.NewService(bundle.NewRepository(dbConn))
 	keyService := key.NewService(key.NewRepository(dbConn))
 	macService := mac.NewService(mac.NewRepository(dbConn))
+	if a.config.Clients.VendorRegistry != "" {
+		if _, err := macService.LoadRegistryFile(a.config.Clients.VendorRegistry); err != nil {
+			log.Warning("Using embedded vendor registry, %v", err)
+		}
+	}
 	notificationService := notification.NewService(notification.NewRepository(dbConn))
+	notificationService.Syslog = a.context.Syslog
 	prefetchService := prefetch.NewService(prefetch.NewRepository(dbConn), a.context.DNSServer)
 	requestService := request.NewService(request.NewRepository(dbConn))
-	resolutionService := resolution.NewService(resolution.NewRepository())
+	resolutionService := resolution.NewService(resolution.NewRepository(dbConn))
+	if err := resolutionService.ImportFromSettings(a.config); err != nil {
+		log.Warning("%v", err)
+	}
 	userService := user.NewService(user.NewRepository(dbConn))
 	whitelistService := whitelist.NewService(whitelist.NewRepository(dbConn))
+	zoneService := zone.NewService(zone.DefaultDirectory)
+	leaseService := dhcp.NewService(a.config.Clients.Leases)
 
 	a.context.DNSServer.AlertService = alertService
 	a.context.DNSServer.AuditService = auditService
 	a.context.DNSServer.BlacklistService = blacklistService
+	a.context.DNSServer.BundleService = bundleService
 	a.context.DNSServer.MACService = macService
 	a.context.DNSServer.NotificationService = notificationService
 	a.context.DNSServer.RequestService = requestService
 	a.context.DNSServer.UserService = userService
 	a.context.DNSServer.ResolutionService = resolutionService
 	a.context.DNSServer.WhitelistService = whitelistService
+	a.context.DNSServer.ZoneService = zoneService
+	a.context.DNSServer.LeaseService = leaseService
 
 	a.displayStartupInfo()
 
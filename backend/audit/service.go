@@ -2,6 +2,7 @@ package audit
 
 import (
 	"goaway/backend/logging"
+	"goaway/backend/syslog"
 	"time"
 )
 
@@ -32,6 +33,9 @@ var log = logging.GetLogger()
 
 type Service struct {
 	repository Repository
+
+	// Forwards audit entries to a remote syslog server
+	Syslog *syslog.Forwarder
 }
 
 func NewService(repo Repository) *Service {
@@ -43,6 +47,7 @@ func (s *Service) CreateAudit(entry *Entry) {
 	if err != nil {
 		log.Warning("Could not create audit: %v", err)
 	}
+	s.Syslog.Audit(string(entry.Topic), entry.Message)
 }
 
 func (s *Service) ReadAudits() ([]Entry, error) {
@@ -14,3 +14,13 @@ type BlocklistSource struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
 }
+
+// DomainMatch describes a single blacklist entry, exact or wildcard, that matches a queried domain.
+type DomainMatch struct {
+	Pattern    string `json:"pattern"`
+	SourceName string `json:"sourceName"`
+	SourceURL  string `json:"sourceURL"`
+	SourceID   uint   `json:"sourceID"`
+	Wildcard   bool   `json:"wildcard"`
+	Active     bool   `json:"active"`
+}
@@ -28,6 +28,7 @@ type SourceRepository interface {
 type DomainRepository interface {
 	GetAllDomains(ctx context.Context) ([]string, error)
 	GetDomainsForSource(ctx context.Context, sourceName string) ([]string, error)
+	GetDomainMatches(ctx context.Context, patterns []string) ([]DomainMatch, error)
 	GetPaginatedDomains(ctx context.Context, page, pageSize int, search string) ([]database.Blacklist, int64, error)
 	CountDomains(ctx context.Context) (int64, error)
 	CreateDomain(ctx context.Context, domain *database.Blacklist) error
@@ -43,6 +44,18 @@ type StatsRepository interface {
 	GetRequestStats(ctx context.Context) ([]RequestStats, error)
 }
 
+type RevisionRepository interface {
+	CreateRevision(ctx context.Context, revision *database.SourceRevision) error
+	GetRevisions(ctx context.Context, name, url string) ([]database.SourceRevision, error)
+	GetRevision(ctx context.Context, id uint) (*database.SourceRevision, error)
+	GetLatestRevision(ctx context.Context, name, url string) (*database.SourceRevision, error)
+	GetPreviousRevision(ctx context.Context, name, url string, beforeID uint) (*database.SourceRevision, error)
+	IsChecksumReverted(ctx context.Context, name, url, checksum string) bool
+	MarkRevisionsReverted(ctx context.Context, name, url string, afterID uint) error
+	PruneRevisions(ctx context.Context, name, url string, keep int) error
+	DeleteRevisions(ctx context.Context, name, url string) error
+}
+
 type TransactionRepository interface {
 	WithTransaction(ctx context.Context, fn func(*gorm.DB) error) error
 	Vacuum(ctx context.Context) error
@@ -52,6 +65,7 @@ type Repository interface {
 	SourceRepository
 	DomainRepository
 	StatsRepository
+	RevisionRepository
 	TransactionRepository
 }
 
@@ -230,6 +244,26 @@ func (r *repository) GetDomainsForSource(ctx context.Context, sourceName string)
 	return domains, nil
 }
 
+func (r *repository) GetDomainMatches(ctx context.Context, patterns []string) ([]DomainMatch, error) {
+	var matches []DomainMatch
+	if len(patterns) == 0 {
+		return matches, nil
+	}
+
+	result := r.db.WithContext(ctx).Table("blacklists").
+		Select("blacklists.domain AS pattern, sources.id AS source_id, sources.name AS source_name, sources.url AS source_url, sources.active AS active").
+		Joins("JOIN sources ON blacklists.source_id = sources.id").
+		Where("blacklists.domain IN ?", patterns).
+		Order("sources.name").
+		Scan(&matches)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query matching domains: %w", result.Error)
+	}
+
+	return matches, nil
+}
+
 func (r *repository) GetPaginatedDomains(ctx context.Context, page, pageSize int, search string) ([]database.Blacklist, int64, error) {
 	searchPattern := "%" + search + "%"
 	offset := (page - 1) * pageSize
@@ -370,6 +404,119 @@ func (r *repository) GetRequestStats(ctx context.Context) ([]RequestStats, error
 	return stats, nil
 }
 
+func (r *repository) CreateRevision(ctx context.Context, revision *database.SourceRevision) error {
+	if err := r.db.WithContext(ctx).Create(revision).Error; err != nil {
+		return fmt.Errorf("failed to create revision for '%s': %w", revision.SourceName, err)
+	}
+	return nil
+}
+
+func (r *repository) GetRevisions(ctx context.Context, name, url string) ([]database.SourceRevision, error) {
+	var revisions []database.SourceRevision
+	result := r.db.WithContext(ctx).Omit("domains").
+		Where("source_name = ? AND source_url = ?", name, url).
+		Order("id DESC").
+		Find(&revisions)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query revisions for '%s': %w", name, result.Error)
+	}
+
+	return revisions, nil
+}
+
+func (r *repository) GetRevision(ctx context.Context, id uint) (*database.SourceRevision, error) {
+	var revision database.SourceRevision
+	if err := r.db.WithContext(ctx).First(&revision, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("revision %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	return &revision, nil
+}
+
+func (r *repository) GetLatestRevision(ctx context.Context, name, url string) (*database.SourceRevision, error) {
+	var revision database.SourceRevision
+	err := r.db.WithContext(ctx).
+		Where("source_name = ? AND source_url = ?", name, url).
+		Order("id DESC").
+		First(&revision).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest revision: %w", err)
+	}
+	return &revision, nil
+}
+
+func (r *repository) GetPreviousRevision(ctx context.Context, name, url string, beforeID uint) (*database.SourceRevision, error) {
+	var revision database.SourceRevision
+	err := r.db.WithContext(ctx).
+		Where("source_name = ? AND source_url = ? AND id < ?", name, url, beforeID).
+		Order("id DESC").
+		First(&revision).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get previous revision: %w", err)
+	}
+	return &revision, nil
+}
+
+func (r *repository) IsChecksumReverted(ctx context.Context, name, url, checksum string) bool {
+	var revision database.SourceRevision
+	err := r.db.WithContext(ctx).Omit("domains").
+		Where("source_name = ? AND source_url = ? AND checksum = ?", name, url, checksum).
+		Order("id DESC").
+		First(&revision).Error
+
+	return err == nil && revision.Reverted
+}
+
+func (r *repository) MarkRevisionsReverted(ctx context.Context, name, url string, afterID uint) error {
+	result := r.db.WithContext(ctx).Model(&database.SourceRevision{}).
+		Where("source_name = ? AND source_url = ? AND id > ?", name, url, afterID).
+		Update("reverted", true)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark revisions as reverted: %w", result.Error)
+	}
+	return nil
+}
+
+func (r *repository) PruneRevisions(ctx context.Context, name, url string, keep int) error {
+	keepIDs := r.db.WithContext(ctx).Model(&database.SourceRevision{}).
+		Select("id").
+		Where("source_name = ? AND source_url = ?", name, url).
+		Order("id DESC").
+		Limit(keep)
+
+	result := r.db.WithContext(ctx).
+		Where("source_name = ? AND source_url = ? AND id NOT IN (?)", name, url, keepIDs).
+		Delete(&database.SourceRevision{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to prune revisions for '%s': %w", name, result.Error)
+	}
+	return nil
+}
+
+func (r *repository) DeleteRevisions(ctx context.Context, name, url string) error {
+	result := r.db.WithContext(ctx).
+		Where("source_name = ? AND source_url = ?", name, url).
+		Delete(&database.SourceRevision{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove revisions for '%s': %w", name, result.Error)
+	}
+	return nil
+}
+
 func (r *repository) Vacuum(ctx context.Context) error {
 	if err := r.db.WithContext(ctx).Exec("VACUUM").Error; err != nil {
 		return fmt.Errorf("error while vacuuming database: %w", err)
@@ -0,0 +1,236 @@
+package blacklist
+
+import (
+	"context"
+	"fmt"
+	"goaway/backend/database"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	testListName = "Ads"
+	testListURL  = "https://lists.example.org/ads.txt"
+)
+
+// hostsServer serves its current body for every list URL.
+type hostsServer struct {
+	body string
+}
+
+func (h *hostsServer) Get(url string) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(h.body))}, nil
+}
+
+// setupService returns a service backed by an in-memory database. Shared cache is needed, like in
+// production, because transactions run their statements on other connections of the pool.
+func setupService(t *testing.T, lists HTTPClient) (*Service, Repository) {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewRepository(db)
+	service := &Service{
+		repository: repo,
+		httpClient: lists,
+		cache:      make(map[string]bool),
+		config:     defaultConfig,
+	}
+	return service, repo
+}
+
+// updateList replaces the list contents the way the scheduled update does.
+func updateList(t *testing.T, service *Service, lists *hostsServer, body string) {
+	ctx := context.Background()
+	lists.body = body
+
+	if err := service.SnapshotSource(ctx, testListName, testListURL); err != nil {
+		t.Fatalf("SnapshotSource failed: %v", err)
+	}
+	if service.repository.GetSourceExists(ctx, testListName, testListURL) {
+		if err := service.RemoveSourceAndDomains(ctx, testListName, testListURL); err != nil {
+			t.Fatalf("RemoveSourceAndDomains failed: %v", err)
+		}
+	}
+	if err := service.FetchAndLoadHosts(ctx, testListURL, testListName); err != nil {
+		t.Fatalf("FetchAndLoadHosts failed: %v", err)
+	}
+}
+
+func TestRollbackSource(t *testing.T) {
+	lists := &hostsServer{}
+	service, repo := setupService(t, lists)
+	ctx := context.Background()
+
+	updateList(t, service, lists, "0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n")
+	updateList(t, service, lists, "0.0.0.0 tracker.example.com\n0.0.0.0 malware.example.net\n")
+
+	revisions, err := repo.GetRevisions(ctx, testListName, testListURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("got %d revisions, want 2", len(revisions))
+	}
+	first, second := revisions[1], revisions[0]
+
+	if _, err := service.RollbackSource(ctx, first.ID); err != nil {
+		t.Fatalf("RollbackSource failed: %v", err)
+	}
+
+	domains, checksum, err := service.FetchDBHostsList(ctx, testListName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ads.example.com", "tracker.example.com"}; !slices.Equal(domains, want) {
+		t.Errorf("domains after rollback = %v, want %v", domains, want)
+	}
+	if checksum != first.Checksum {
+		t.Errorf("checksum after rollback = %s, want %s", checksum, first.Checksum)
+	}
+
+	for domain, want := range map[string]bool{"ads.example.com": true, "malware.example.net": false} {
+		if got := service.IsBlacklisted(domain); got != want {
+			t.Errorf("IsBlacklisted(%s) = %v, want %v", domain, got, want)
+		}
+	}
+
+	if !service.IsUpdateReverted(ctx, testListName, testListURL, second.Checksum) {
+		t.Error("rolled back contents should be skipped by the scheduled update")
+	}
+	if service.IsUpdateReverted(ctx, testListName, testListURL, first.Checksum) {
+		t.Error("restored contents should not be reported as reverted")
+	}
+
+	revisions, err = repo.GetRevisions(ctx, testListName, testListURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 3 || revisions[0].Origin != RevisionOriginRollback {
+		t.Errorf("latest revision origin = %s of %d, want %s of 3", revisions[0].Origin, len(revisions), RevisionOriginRollback)
+	}
+}
+
+func TestSnapshotSource(t *testing.T) {
+	service, repo := setupService(t, &hostsServer{})
+	ctx := context.Background()
+
+	// Lists loaded before revisions existed have domains but no revision
+	if err := service.InitializeBlocklist(ctx, testListName, testListURL); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.AddDomains(ctx, testListName, []string{"ads.example.com", "tracker.example.com"}, testListURL); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := service.SnapshotSource(ctx, testListName, testListURL); err != nil {
+			t.Fatalf("SnapshotSource failed: %v", err)
+		}
+	}
+
+	revisions, err := repo.GetRevisions(ctx, testListName, testListURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("got %d revisions, want 1", len(revisions))
+	}
+	if revisions[0].Origin != RevisionOriginSnapshot || revisions[0].DomainCount != 2 {
+		t.Errorf("snapshot = %s with %d domains, want %s with 2", revisions[0].Origin, revisions[0].DomainCount, RevisionOriginSnapshot)
+	}
+}
+
+func TestPruneRevisions(t *testing.T) {
+	_, repo := setupService(t, &hostsServer{})
+	ctx := context.Background()
+
+	create := func(name, url string, count int) {
+		for i := range count {
+			revision := &database.SourceRevision{SourceName: name, SourceURL: url, Checksum: fmt.Sprint(i), Origin: RevisionOriginFetch}
+			if err := repo.CreateRevision(ctx, revision); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	create(testListName, testListURL, 8)
+	create("Other", "https://lists.example.org/other.txt", 3)
+
+	if err := repo.PruneRevisions(ctx, testListName, testListURL, 5); err != nil {
+		t.Fatalf("PruneRevisions failed: %v", err)
+	}
+
+	revisions, err := repo.GetRevisions(ctx, testListName, testListURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checksums []string
+	for _, revision := range revisions {
+		checksums = append(checksums, revision.Checksum)
+	}
+	if want := []string{"7", "6", "5", "4", "3"}; !slices.Equal(checksums, want) {
+		t.Errorf("kept revisions = %v, want %v", checksums, want)
+	}
+
+	other, err := repo.GetRevisions(ctx, "Other", "https://lists.example.org/other.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other) != 3 {
+		t.Errorf("pruning removed revisions of another list, %d left, want 3", len(other))
+	}
+}
+
+func TestIsChecksumReverted(t *testing.T) {
+	_, repo := setupService(t, &hostsServer{})
+	ctx := context.Background()
+
+	var revisions []*database.SourceRevision
+	for _, checksum := range []string{"a", "b", "c"} {
+		revision := &database.SourceRevision{SourceName: testListName, SourceURL: testListURL, Checksum: checksum, Origin: RevisionOriginFetch}
+		if err := repo.CreateRevision(ctx, revision); err != nil {
+			t.Fatal(err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := repo.MarkRevisionsReverted(ctx, testListName, testListURL, revisions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		checksum string
+		want     bool
+	}{
+		{testListName, testListURL, "a", false},
+		{testListName, testListURL, "b", true},
+		{testListName, testListURL, "c", true},
+		{testListName, testListURL, "unknown", false},
+		{"Other", "https://lists.example.org/other.txt", "b", false},
+	}
+
+	for _, tt := range tests {
+		if got := repo.IsChecksumReverted(ctx, tt.name, tt.url, tt.checksum); got != tt.want {
+			t.Errorf("IsChecksumReverted(%s, %s) = %v, want %v", tt.name, tt.checksum, got, tt.want)
+		}
+	}
+}
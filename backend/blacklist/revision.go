@@ -0,0 +1,230 @@
+package blacklist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"goaway/backend/database"
+	"io"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Number of revisions kept for every list, older revisions are pruned when a new one is recorded.
+const maxRevisionsPerSource = 5
+
+const (
+	RevisionOriginFetch    = "fetch"
+	RevisionOriginSnapshot = "snapshot"
+	RevisionOriginRollback = "rollback"
+)
+
+// RevisionDiff is a revision together with the domains added and removed compared to the revision before it.
+type RevisionDiff struct {
+	Revision database.SourceRevision `json:"revision"`
+	Added    []string                `json:"added"`
+	Removed  []string                `json:"removed"`
+}
+
+func (s *Service) GetRevisions(ctx context.Context, name, url string) ([]database.SourceRevision, error) {
+	return s.repository.GetRevisions(ctx, name, url)
+}
+
+func (s *Service) GetRevisionDiff(ctx context.Context, id uint) (RevisionDiff, error) {
+	revision, err := s.repository.GetRevision(ctx, id)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	domains, err := decodeRevisionDomains(revision.Domains)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	var previousDomains []string
+	previous, err := s.repository.GetPreviousRevision(ctx, revision.SourceName, revision.SourceURL, revision.ID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	if previous != nil {
+		previousDomains, err = decodeRevisionDomains(previous.Domains)
+		if err != nil {
+			return RevisionDiff{}, err
+		}
+	}
+
+	return RevisionDiff{
+		Revision: *revision,
+		Added:    diffDomains(domains, previousDomains),
+		Removed:  diffDomains(previousDomains, domains),
+	}, nil
+}
+
+// SnapshotSource records the list contents currently stored in the database, unless they are already the
+// latest revision. Called before a list is replaced so that lists loaded before revisions existed can be rolled back.
+func (s *Service) SnapshotSource(ctx context.Context, name, url string) error {
+	domains, checksum, err := s.FetchDBHostsList(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		return nil
+	}
+
+	latest, err := s.repository.GetLatestRevision(ctx, name, url)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.Checksum == checksum {
+		return nil
+	}
+
+	return s.recordRevision(ctx, name, url, domains, checksum, RevisionOriginSnapshot)
+}
+
+// RollbackSource replaces the domains of a list with the contents of a stored revision.
+// Revisions newer than the restored one are marked as reverted so that scheduled updates do not re-apply them.
+func (s *Service) RollbackSource(ctx context.Context, id uint) (*database.SourceRevision, error) {
+	revision, err := s.repository.GetRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := decodeRevisionDomains(revision.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SnapshotSource(ctx, revision.SourceName, revision.SourceURL); err != nil {
+		return nil, fmt.Errorf("failed to snapshot current list: %w", err)
+	}
+
+	err = s.repository.WithTransaction(ctx, func(tx *gorm.DB) error {
+		source, err := s.repository.GetSourceByNameAndURL(ctx, revision.SourceName, revision.SourceURL)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repository.DeleteDomainsBySourceID(ctx, source.ID); err != nil {
+			return err
+		}
+
+		entries := make([]database.Blacklist, 0, len(domains))
+		for _, domain := range domains {
+			entries = append(entries, database.Blacklist{Domain: domain, SourceID: source.ID})
+		}
+
+		batchSize := s.config.BatchSize
+		if batchSize == 0 {
+			batchSize = defaultBatchSize
+		}
+		if err := s.repository.CreateDomainsInBatches(ctx, entries, batchSize); err != nil {
+			return err
+		}
+
+		return s.repository.UpdateSourceLastUpdated(ctx, revision.SourceURL, time.Now())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore revision %d: %w", id, err)
+	}
+
+	if err := s.repository.MarkRevisionsReverted(ctx, revision.SourceName, revision.SourceURL, revision.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordRevision(ctx, revision.SourceName, revision.SourceURL, domains, revision.Checksum, RevisionOriginRollback); err != nil {
+		log.Warning("Failed to record rollback revision for %s: %v", revision.SourceName, err)
+	}
+
+	if err := s.PopulateCache(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Info("Rolled back list '%s' to revision %d (%d domains)", revision.SourceName, revision.ID, len(domains))
+	return revision, nil
+}
+
+func (s *Service) RemoveRevisions(ctx context.Context, name, url string) error {
+	return s.repository.DeleteRevisions(ctx, name, url)
+}
+
+// IsUpdateReverted reports whether the given remote contents were previously rolled back by an admin.
+func (s *Service) IsUpdateReverted(ctx context.Context, name, url, checksum string) bool {
+	return s.repository.IsChecksumReverted(ctx, name, url, checksum)
+}
+
+func (s *Service) recordRevision(ctx context.Context, name, url string, domains []string, checksum, origin string) error {
+	encoded, err := encodeRevisionDomains(domains)
+	if err != nil {
+		return err
+	}
+
+	revision := &database.SourceRevision{
+		SourceName:  name,
+		SourceURL:   url,
+		Checksum:    checksum,
+		Origin:      origin,
+		Domains:     encoded,
+		DomainCount: len(domains),
+	}
+
+	latest, err := s.repository.GetLatestRevision(ctx, name, url)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		previousDomains, err := decodeRevisionDomains(latest.Domains)
+		if err != nil {
+			return err
+		}
+		revision.AddedCount = len(diffDomains(domains, previousDomains))
+		revision.RemovedCount = len(diffDomains(previousDomains, domains))
+	} else {
+		revision.AddedCount = len(domains)
+	}
+
+	if err := s.repository.CreateRevision(ctx, revision); err != nil {
+		return err
+	}
+
+	return s.repository.PruneRevisions(ctx, name, url, maxRevisionsPerSource)
+}
+
+func encodeRevisionDomains(domains []string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(writer, strings.Join(domains, "\n")); err != nil {
+		return nil, fmt.Errorf("failed to compress revision: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress revision: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeRevisionDomains(data []byte) ([]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress revision: %w", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress revision: %w", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	return strings.Split(string(raw), "\n"), nil
+}
@@ -0,0 +1,36 @@
+package blacklist
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRevisionDomainsRoundTrip(t *testing.T) {
+	domains := []string{"ads.example.com", "tracker.example.net", "*.doubleclick.net"}
+
+	encoded, err := encodeRevisionDomains(domains)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	decoded, err := decodeRevisionDomains(encoded)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	if !slices.Equal(decoded, domains) {
+		t.Errorf("decoded = %v, want %v", decoded, domains)
+	}
+}
+
+func TestDiffDomains(t *testing.T) {
+	previous := []string{"a.com", "b.com", "c.com"}
+	current := []string{"b.com", "c.com", "d.com"}
+
+	if added := diffDomains(current, previous); !slices.Equal(added, []string{"d.com"}) {
+		t.Errorf("added = %v, want [d.com]", added)
+	}
+	if removed := diffDomains(previous, current); !slices.Equal(removed, []string{"a.com"}) {
+		t.Errorf("removed = %v, want [a.com]", removed)
+	}
+}
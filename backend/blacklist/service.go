@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"goaway/backend/database"
 	"goaway/backend/logging"
+	"goaway/backend/metrics"
 	"io"
 	"net/http"
 	"slices"
@@ -203,6 +204,34 @@ func matchesWildcard(domain, pattern string) bool {
 	return len(domain) > len(suffix)
 }
 
+// FindMatches returns every blacklist entry, across all sources, that would cause the domain to be blocked.
+func (s *Service) FindMatches(ctx context.Context, domain string) ([]DomainMatch, error) {
+	domain = strings.TrimSuffix(domain, ".")
+	patterns := append([]string{domain}, wildcardCandidates(domain)...)
+
+	matches, err := s.repository.GetDomainMatches(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range matches {
+		matches[i].Wildcard = strings.HasPrefix(matches[i].Pattern, "*.")
+	}
+
+	return matches, nil
+}
+
+// wildcardCandidates returns all wildcard patterns that could match the domain
+// Example: "a.b.example.com" -> ["*.b.example.com", "*.example.com", "*.com"]
+func wildcardCandidates(domain string) []string {
+	labels := strings.Split(strings.TrimSuffix(domain, "."), ".")
+	candidates := make([]string, 0, len(labels))
+	for i := 1; i < len(labels); i++ {
+		candidates = append(candidates, "*."+strings.Join(labels[i:], "."))
+	}
+	return candidates
+}
+
 func (s *Service) GetBlocklistUrls(ctx context.Context) ([]BlocklistSource, error) {
 	sources, err := s.repository.GetSources(ctx, true)
 	if err != nil {
@@ -259,31 +288,32 @@ func (s *Service) CheckIfUpdateAvailable(ctx context.Context, remoteListURL, lis
 		return listUpdateAvailable, nil
 	}
 
-	diff := func(a, b []string) []string {
-		mb := make(map[string]struct{}, len(b))
-		for _, x := range b {
-			mb[x] = struct{}{}
-		}
-		diff := make([]string, 0)
-		for _, x := range a {
-			if _, found := mb[x]; !found {
-				diff = append(diff, x)
-			}
-		}
-		return diff
-	}
-
 	return ListUpdateAvailable{
 		RemoteDomains:   remoteDomains,
 		DBDomains:       dbDomains,
 		RemoteChecksum:  remoteChecksum,
 		DBChecksum:      dbChecksum,
 		UpdateAvailable: true,
-		DiffAdded:       diff(remoteDomains, dbDomains),
-		DiffRemoved:     diff(dbDomains, remoteDomains),
+		DiffAdded:       diffDomains(remoteDomains, dbDomains),
+		DiffRemoved:     diffDomains(dbDomains, remoteDomains),
 	}, nil
 }
 
+// diffDomains returns the domains in a that are not present in b
+func diffDomains(a, b []string) []string {
+	mb := make(map[string]struct{}, len(b))
+	for _, x := range b {
+		mb[x] = struct{}{}
+	}
+	diff := make([]string, 0)
+	for _, x := range a {
+		if _, found := mb[x]; !found {
+			diff = append(diff, x)
+		}
+	}
+	return diff
+}
+
 func (s *Service) FetchRemoteHostsList(ctx context.Context, url string) ([]string, string, error) {
 	resp, err := s.httpClient.Get(url)
 	if err != nil {
@@ -339,6 +369,10 @@ func (s *Service) FetchAndLoadHosts(ctx context.Context, url, name string) error
 		return fmt.Errorf("failed to add domains to database: %w", err)
 	}
 
+	if err := s.recordRevision(ctx, name, url, domains, calculateDomainsChecksum(domains), RevisionOriginFetch); err != nil {
+		log.Warning("Failed to record revision for list '%s': %v", name, err)
+	}
+
 	log.Info("Added %d domains from list '%s' with url '%s'", len(domains), name, url)
 	return nil
 }
@@ -769,25 +803,40 @@ func (s *Service) ScheduleAutomaticListUpdates(ctx context.Context) {
 				availableUpdate, err := s.CheckIfUpdateAvailable(bgCtx, source.URL, source.Name)
 				if err != nil {
 					log.Warning("Failed to check for updates for %s: %v", source.Name, err)
+					metrics.ObserveListUpdate(source.Name, metrics.ListFailed)
 					continue
 				}
 
 				if !availableUpdate.UpdateAvailable {
 					log.Info("No updates available for %s", source.Name)
+					metrics.ObserveListUpdate(source.Name, metrics.ListUnchanged)
 					continue
 				}
 
+				if s.IsUpdateReverted(bgCtx, source.Name, source.URL, availableUpdate.RemoteChecksum) {
+					log.Info("Skipping update for %s, this version was previously rolled back", source.Name)
+					metrics.ObserveListUpdate(source.Name, metrics.ListSkipped)
+					continue
+				}
+
+				if err := s.SnapshotSource(bgCtx, source.Name, source.URL); err != nil {
+					log.Warning("Failed to snapshot %s before updating: %v", source.Name, err)
+				}
+
 				if err := s.RemoveSourceAndDomains(bgCtx, source.Name, source.URL); err != nil {
 					log.Warning("Failed to remove old domains for %s: %v", source.Name, err)
+					metrics.ObserveListUpdate(source.Name, metrics.ListFailed)
 					continue
 				}
 
 				if err := s.FetchAndLoadHosts(bgCtx, source.URL, source.Name); err != nil {
 					log.Warning("Failed to fetch and load hosts for %s: %v", source.Name, err)
+					metrics.ObserveListUpdate(source.Name, metrics.ListFailed)
 					continue
 				}
 
 				log.Info("Successfully updated %s with %d new domains", source.Name, len(availableUpdate.DiffAdded))
+				metrics.ObserveListUpdate(source.Name, metrics.ListUpdated)
 			}
 
 			if err := s.PopulateCache(bgCtx); err != nil {
@@ -1,6 +1,7 @@
 package blacklist
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -167,3 +168,41 @@ func TestIsValidDomainOrWildcard(t *testing.T) {
 		})
 	}
 }
+
+func TestWildcardCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		expected []string
+	}{
+		{
+			name:     "subdomain",
+			domain:   "a.b.example.com",
+			expected: []string{"*.b.example.com", "*.example.com", "*.com"},
+		},
+		{
+			name:     "trailing dot",
+			domain:   "test.example.com.",
+			expected: []string{"*.example.com", "*.com"},
+		},
+		{
+			name:     "single label",
+			domain:   "localhost",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := wildcardCandidates(tt.domain)
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("wildcardCandidates(%q) = %v, want %v", tt.domain, result, tt.expected)
+			}
+			for _, candidate := range result {
+				if !matchesWildcard(tt.domain, candidate) {
+					t.Errorf("candidate %q does not match %q", candidate, tt.domain)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package bundle
+
+import (
+	"slices"
+	"strings"
+)
+
+// Bundle is a service that can be blocked as a whole.
+// Every domain also matches all of its subdomains.
+type Bundle struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+}
+
+var catalog = []Bundle{
+	{
+		ID:   "discord",
+		Name: "Discord",
+		Domains: []string{
+			"discord.com", "discord.gg", "discord.media", "discordapp.com", "discordapp.net",
+			"discordcdn.com", "discord.dev", "discord.new", "discordstatus.com",
+		},
+	},
+	{
+		ID:   "epicgames",
+		Name: "Epic Games",
+		Domains: []string{
+			"epicgames.com", "epicgames.dev", "unrealengine.com", "fortnite.com", "easyanticheat.net",
+		},
+	},
+	{
+		ID:   "facebook",
+		Name: "Facebook",
+		Domains: []string{
+			"facebook.com", "facebook.net", "fb.com", "fb.me", "fbcdn.net", "fbsbx.com",
+			"messenger.com", "facebook.de", "facebook.fr",
+		},
+	},
+	{
+		ID:   "instagram",
+		Name: "Instagram",
+		Domains: []string{
+			"instagram.com", "cdninstagram.com", "ig.me", "instagr.am",
+		},
+	},
+	{
+		ID:   "netflix",
+		Name: "Netflix",
+		Domains: []string{
+			"netflix.com", "netflix.net", "nflxext.com", "nflximg.com", "nflximg.net",
+			"nflxso.net", "nflxvideo.net", "fast.com",
+		},
+	},
+	{
+		ID:   "reddit",
+		Name: "Reddit",
+		Domains: []string{
+			"reddit.com", "redd.it", "redditmedia.com", "redditstatic.com", "reddituploads.com",
+		},
+	},
+	{
+		ID:   "roblox",
+		Name: "Roblox",
+		Domains: []string{
+			"roblox.com", "rbxcdn.com", "rbx.com", "robloxlabs.com",
+		},
+	},
+	{
+		ID:   "snapchat",
+		Name: "Snapchat",
+		Domains: []string{
+			"snapchat.com", "snap.com", "snapkit.com", "snapads.com", "sc-cdn.net", "snap-dev.net",
+		},
+	},
+	{
+		ID:   "spotify",
+		Name: "Spotify",
+		Domains: []string{
+			"spotify.com", "scdn.co", "spotifycdn.com", "spotifycdn.net", "spoti.fi", "pscdn.co",
+		},
+	},
+	{
+		ID:   "steam",
+		Name: "Steam",
+		Domains: []string{
+			"steampowered.com", "steamcommunity.com", "steamstatic.com", "steamcontent.com",
+			"steamserver.net", "steamgames.com", "steamusercontent.com", "valvesoftware.com",
+		},
+	},
+	{
+		ID:   "tiktok",
+		Name: "TikTok",
+		Domains: []string{
+			"tiktok.com", "tiktokv.com", "tiktokcdn.com", "tiktokcdn-us.com", "tiktokv.us",
+			"byteoversea.com", "ibytedtos.com", "ibyteimg.com", "muscdn.com", "musical.ly",
+		},
+	},
+	{
+		ID:   "twitch",
+		Name: "Twitch",
+		Domains: []string{
+			"twitch.tv", "twitchcdn.net", "twitchsvc.net", "jtvnw.net", "ttvnw.net", "ext-twitch.tv",
+		},
+	},
+	{
+		ID:   "twitter",
+		Name: "X (Twitter)",
+		Domains: []string{
+			"twitter.com", "x.com", "t.co", "twimg.com", "twttr.com", "twitpic.com",
+		},
+	},
+	{
+		ID:   "whatsapp",
+		Name: "WhatsApp",
+		Domains: []string{
+			"whatsapp.com", "whatsapp.net", "wa.me",
+		},
+	},
+	{
+		ID:   "youtube",
+		Name: "YouTube",
+		Domains: []string{
+			"youtube.com", "youtu.be", "ytimg.com", "googlevideo.com", "youtube-nocookie.com",
+			"youtubei.googleapis.com", "yt.be",
+		},
+	},
+}
+
+// Maps every domain of the catalogue to the IDs of the bundles it belongs to
+var catalogDomains = indexCatalog()
+
+func indexCatalog() map[string][]string {
+	domains := make(map[string][]string)
+	for _, bundle := range catalog {
+		for _, domain := range bundle.Domains {
+			domains[domain] = append(domains[domain], bundle.ID)
+		}
+	}
+	return domains
+}
+
+// matchBundles returns the IDs of the bundles the domain, or one of its parent domains, belongs to.
+func matchBundles(domain string) []string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	var ids []string
+	for {
+		for _, id := range catalogDomains[domain] {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+
+		idx := strings.IndexByte(domain, '.')
+		if idx == -1 {
+			return ids
+		}
+		domain = domain[idx+1:]
+	}
+}
+
+func findBundle(id string) (Bundle, bool) {
+	for _, bundle := range catalog {
+		if bundle.ID == id {
+			return bundle, true
+		}
+	}
+	return Bundle{}, false
+}
@@ -0,0 +1,67 @@
+package bundle
+
+import (
+	"context"
+	"goaway/backend/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	GetBlocked(ctx context.Context) ([]string, error)
+	Block(ctx context.Context, id string) error
+	Unblock(ctx context.Context, id string) error
+	GetDomainHits(ctx context.Context, since time.Time) (map[string]Hits, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetBlocked(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&database.BlockedBundle{}).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *repository) Block(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&database.BlockedBundle{ID: id}).Error
+}
+
+func (r *repository) Unblock(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).
+		Delete(&database.BlockedBundle{}, "id = ?", id).Error
+}
+
+// GetDomainHits counts the queries logged since the given time for every domain, which are fully qualified.
+func (r *repository) GetDomainHits(ctx context.Context, since time.Time) (map[string]Hits, error) {
+	var rows []struct {
+		Domain string
+		Hits
+	}
+	err := r.db.WithContext(ctx).
+		Table("request_logs").
+		Select("domain, COUNT(*) AS total, COALESCE(SUM(CASE WHEN blocked THEN 1 ELSE 0 END), 0) AS blocked_any").
+		Where("timestamp >= ?", since).
+		Group("domain").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make(map[string]Hits, len(rows))
+	for _, row := range rows {
+		hits[row.Domain] = row.Hits
+	}
+	return hits, nil
+}
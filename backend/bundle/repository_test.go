@@ -0,0 +1,51 @@
+package bundle
+
+import (
+	"context"
+	"goaway/backend/database"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGetDomainHits(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	logs := []database.RequestLog{
+		{Timestamp: now, Domain: "reddit.com.", ClientIP: "192.168.1.2", Blocked: true},
+		{Timestamp: now, Domain: "reddit.com.", ClientIP: "192.168.1.2"},
+		{Timestamp: now, Domain: "www.reddit.com.", ClientIP: "192.168.1.3", Blocked: true},
+		{Timestamp: now.Add(-48 * time.Hour), Domain: "reddit.com.", ClientIP: "192.168.1.2", Blocked: true},
+		{Timestamp: now.Add(-48 * time.Hour), Domain: "discord.com.", ClientIP: "192.168.1.2"},
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := NewRepository(db).GetDomainHits(context.Background(), now.Add(-hitsWindow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Hits{
+		"reddit.com.":     {Total: 2, BlockedAny: 1},
+		"www.reddit.com.": {Total: 1, BlockedAny: 1},
+	}
+	if len(hits) != len(want) {
+		t.Errorf("got %d domains, want %d", len(hits), len(want))
+	}
+	for domain, expected := range want {
+		if hits[domain] != expected {
+			t.Errorf("hits[%s] = %+v, want %+v", domain, hits[domain], expected)
+		}
+	}
+}
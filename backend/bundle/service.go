@@ -0,0 +1,167 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"goaway/backend/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logging.GetLogger()
+
+// Period over which bundle hits are counted. Bounding it keeps /bundles cheap on large query logs.
+const hitsWindow = 24 * time.Hour
+
+// Hits counts the queries logged over the last day for the domains of a bundle. BlockedAny includes queries
+// blocked for any reason, such as a blocklist, not only those blocked by the bundle itself.
+type Hits struct {
+	Total      int64 `json:"total"`
+	BlockedAny int64 `json:"blockedAny"`
+}
+
+// Status is a bundle from the catalogue together with whether it is blocked and how often it was queried.
+type Status struct {
+	Bundle
+	Blocked bool `json:"blocked"`
+	Hits    Hits `json:"hits"`
+}
+
+type Service struct {
+	repository Repository
+
+	mu      sync.RWMutex
+	blocked map[string]bool
+	// Maps every domain of a blocked bundle to the bundle ID
+	blockedDomains map[string]string
+}
+
+func NewService(repo Repository) *Service {
+	service := &Service{
+		repository:     repo,
+		blocked:        map[string]bool{},
+		blockedDomains: map[string]string{},
+	}
+
+	if err := service.loadBlocked(context.Background()); err != nil {
+		log.Warning("Could not load blocked service bundles, %v", err)
+	}
+
+	return service
+}
+
+func (s *Service) GetBundles(ctx context.Context) ([]Status, error) {
+	domainHits, err := s.repository.GetDomainHits(ctx, time.Now().Add(-hitsWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count service hits: %w", err)
+	}
+
+	hits := make(map[string]Hits, len(catalog))
+	for domain, domainHit := range domainHits {
+		for _, id := range matchBundles(domain) {
+			bundleHits := hits[id]
+			bundleHits.Total += domainHit.Total
+			bundleHits.BlockedAny += domainHit.BlockedAny
+			hits[id] = bundleHits
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bundles := make([]Status, 0, len(catalog))
+	for _, bundle := range catalog {
+		bundles = append(bundles, Status{
+			Bundle:  bundle,
+			Blocked: s.blocked[bundle.ID],
+			Hits:    hits[bundle.ID],
+		})
+	}
+
+	return bundles, nil
+}
+
+func (s *Service) SetBlocked(ctx context.Context, id string, blocked bool) (Bundle, error) {
+	bundle, found := findBundle(id)
+	if !found {
+		return Bundle{}, fmt.Errorf("unknown service '%s'", id)
+	}
+
+	var err error
+	if blocked {
+		err = s.repository.Block(ctx, id)
+	} else {
+		err = s.repository.Unblock(ctx, id)
+	}
+	if err != nil {
+		return Bundle{}, fmt.Errorf("failed to update %s: %w", bundle.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if blocked {
+		s.blocked[id] = true
+	} else {
+		delete(s.blocked, id)
+	}
+	s.rebuildBlockedDomains()
+
+	return bundle, nil
+}
+
+// BlockedBy returns the ID of the blocked bundle the domain, or one of its parent domains, belongs to.
+func (s *Service) BlockedBy(domain string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.blockedDomains) == 0 {
+		return "", false
+	}
+
+	domain = strings.ToLower(domain)
+	for {
+		if id, found := s.blockedDomains[domain]; found {
+			return id, true
+		}
+
+		idx := strings.IndexByte(domain, '.')
+		if idx == -1 {
+			return "", false
+		}
+		domain = domain[idx+1:]
+	}
+}
+
+func (s *Service) loadBlocked(ctx context.Context) error {
+	ids, err := s.repository.GetBlocked(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if _, found := findBundle(id); !found {
+			log.Warning("Ignoring unknown blocked service '%s'", id)
+			continue
+		}
+		s.blocked[id] = true
+	}
+	s.rebuildBlockedDomains()
+
+	return nil
+}
+
+// rebuildBlockedDomains must be called with the lock held.
+func (s *Service) rebuildBlockedDomains() {
+	s.blockedDomains = make(map[string]string)
+	for _, bundle := range catalog {
+		if !s.blocked[bundle.ID] {
+			continue
+		}
+		for _, domain := range bundle.Domains {
+			s.blockedDomains[domain] = bundle.ID
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package bundle
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	blocked []string
+	hits    map[string]Hits
+}
+
+func (r *memoryRepository) GetBlocked(ctx context.Context) ([]string, error) {
+	return r.blocked, nil
+}
+
+func (r *memoryRepository) Block(ctx context.Context, id string) error {
+	if !slices.Contains(r.blocked, id) {
+		r.blocked = append(r.blocked, id)
+	}
+	return nil
+}
+
+func (r *memoryRepository) Unblock(ctx context.Context, id string) error {
+	r.blocked = slices.DeleteFunc(r.blocked, func(blocked string) bool { return blocked == id })
+	return nil
+}
+
+func (r *memoryRepository) GetDomainHits(ctx context.Context, since time.Time) (map[string]Hits, error) {
+	return r.hits, nil
+}
+
+func TestMatchBundles(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   []string
+	}{
+		{"discord.com", []string{"discord"}},
+		{"cdn.discord.com.", []string{"discord"}},
+		{"Media.Discordapp.NET", []string{"discord"}},
+		{"rr3---sn-abc.googlevideo.com.", []string{"youtube"}},
+		{"youtubei.googleapis.com", []string{"youtube"}},
+		{"googleapis.com", nil},
+		{"notdiscord.com", nil},
+		{"discord.com.evil.net", nil},
+		{"x.com", []string{"twitter"}},
+		{"box.com", nil},
+		{"com", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := matchBundles(tt.domain); !slices.Equal(got, tt.want) {
+			t.Errorf("matchBundles(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestBlockedBy(t *testing.T) {
+	service := NewService(&memoryRepository{blocked: []string{"tiktok", "removed"}})
+
+	tests := []struct {
+		domain    string
+		wantID    string
+		wantFound bool
+	}{
+		{"tiktok.com", "tiktok", true},
+		{"www.TikTok.com", "tiktok", true},
+		{"v16.tiktokcdn.com", "tiktok", true},
+		{"mytiktok.com", "", false},
+		{"tiktok.com.example.org", "", false},
+		{"discord.com", "", false},
+	}
+
+	for _, tt := range tests {
+		id, found := service.BlockedBy(tt.domain)
+		if id != tt.wantID || found != tt.wantFound {
+			t.Errorf("BlockedBy(%q) = %q, %v, want %q, %v", tt.domain, id, found, tt.wantID, tt.wantFound)
+		}
+	}
+}
+
+func TestSetBlocked(t *testing.T) {
+	repo := &memoryRepository{}
+	service := NewService(repo)
+	ctx := context.Background()
+
+	if _, err := service.SetBlocked(ctx, "unknown", true); err == nil {
+		t.Error("blocking an unknown service should fail")
+	}
+
+	tests := []struct {
+		id      string
+		blocked bool
+		want    []string
+	}{
+		{"reddit", true, []string{"reddit"}},
+		{"reddit", true, []string{"reddit"}},
+		{"steam", true, []string{"reddit", "steam"}},
+		{"reddit", false, []string{"steam"}},
+		{"steam", false, nil},
+	}
+
+	for _, tt := range tests {
+		bundle, err := service.SetBlocked(ctx, tt.id, tt.blocked)
+		if err != nil {
+			t.Fatalf("SetBlocked(%s, %v) failed: %v", tt.id, tt.blocked, err)
+		}
+		if bundle.ID != tt.id {
+			t.Errorf("SetBlocked(%s, %v) returned %s", tt.id, tt.blocked, bundle.ID)
+		}
+		if !slices.Equal(repo.blocked, tt.want) {
+			t.Errorf("after SetBlocked(%s, %v) blocked = %v, want %v", tt.id, tt.blocked, repo.blocked, tt.want)
+		}
+		if _, found := service.BlockedBy("www.reddit.com"); found != slices.Contains(tt.want, "reddit") {
+			t.Errorf("after SetBlocked(%s, %v) BlockedBy(www.reddit.com) = %v", tt.id, tt.blocked, found)
+		}
+	}
+}
+
+func TestGetBundles(t *testing.T) {
+	service := NewService(&memoryRepository{
+		blocked: []string{"spotify"},
+		hits: map[string]Hits{
+			"spotify.com.":        {Total: 4, BlockedAny: 4},
+			"i.scdn.co.":          {Total: 2, BlockedAny: 1},
+			"notspotify.com.":     {Total: 9, BlockedAny: 0},
+			"www.netflix.com.":    {Total: 3, BlockedAny: 0},
+			"ads.doubleclick.net": {Total: 5, BlockedAny: 5},
+		},
+	})
+
+	bundles, err := service.GetBundles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != len(catalog) {
+		t.Fatalf("got %d bundles, want %d", len(bundles), len(catalog))
+	}
+
+	want := map[string]Status{
+		"spotify": {Blocked: true, Hits: Hits{Total: 6, BlockedAny: 5}},
+		"netflix": {Hits: Hits{Total: 3}},
+		"discord": {},
+	}
+	for _, bundle := range bundles {
+		expected, found := want[bundle.ID]
+		if !found {
+			continue
+		}
+		if bundle.Blocked != expected.Blocked || bundle.Hits != expected.Hits {
+			t.Errorf("%s = %v, %+v, want %v, %+v", bundle.ID, bundle.Blocked, bundle.Hits, expected.Blocked, expected.Hits)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"goaway/backend/settings"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeManager obtains certificates for the configured domains from an ACME CA and renews them before they expire.
+// Challenges are answered with TLS-ALPN-01 on the DoH port, which must be reachable on port 443.
+type acmeManager struct {
+	manager *autocert.Manager
+	domains []string
+}
+
+func newACMEManager(config settings.ACMEConfig, dir string) (*acmeManager, error) {
+	domains := make([]string, 0, len(config.Domains))
+	for _, domain := range config.Domains {
+		if domain = strings.ToLower(strings.TrimSuffix(domain, ".")); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, errors.New("ACME requires at least one domain")
+	}
+
+	client := &acme.Client{DirectoryURL: config.Directory}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = acme.LetsEncryptURL
+	}
+	if config.CA != "" {
+		httpClient, err := httpClientTrusting(config.CA)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = httpClient
+	}
+
+	log.Info("Obtaining certificates for %v from %s", domains, client.DirectoryURL)
+	return &acmeManager{
+		manager: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(filepath.Join(dir, "acme")),
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Client:     client,
+			Email:      config.Email,
+		},
+		domains: domains,
+	}, nil
+}
+
+// GetCertificate returns the certificate for the requested name. Clients connecting without SNI, by IP,
+// or with a client ID in the name get the certificate of the first domain.
+func (m *acmeManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if !slices.Contains(m.domains, name) {
+		fallback := *hello
+		fallback.ServerName = m.domains[0]
+		hello = &fallback
+	}
+	return m.manager.GetCertificate(hello)
+}
+
+// httpClientTrusting returns an HTTP client trusting the CA in caFile, used to reach private ACME directories.
+func httpClientTrusting(caFile string) (*http.Client, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ACME CA: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,410 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"goaway/backend/settings"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+// testCA is a minimal ACME CA (RFC 8555) served over HTTPS, which validates TLS-ALPN-01 challenges
+// (RFC 8737) by connecting to challengeAddr.
+type testCA struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+	root   *x509.Certificate
+
+	mu            sync.Mutex
+	challengeAddr string
+	thumbprint    string
+	authzs        []*testAuthz
+	orders        []*testOrder
+	issued        int
+}
+
+type testChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+type testAuthz struct {
+	Status     string          `json:"status"`
+	Identifier acme.AuthzID    `json:"identifier"`
+	Challenges []testChallenge `json:"challenges"`
+}
+
+type testOrder struct {
+	Status         string         `json:"status"`
+	Identifiers    []acme.AuthzID `json:"identifiers"`
+	Authorizations []string       `json:"authorizations"`
+	Finalize       string         `json:"finalize"`
+	Certificate    string         `json:"certificate,omitempty"`
+
+	chain [][]byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "GoAway Test ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	root, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	ca := &testCA{key: key, root: root}
+	ca.server = httptest.NewTLSServer(http.HandlerFunc(ca.handle))
+	t.Cleanup(ca.server.Close)
+	return ca
+}
+
+// directoryCA writes the certificate of the HTTPS server the directory is served from.
+func (ca *testCA) directoryCA(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "directory-ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(file, data, 0600))
+	return file
+}
+
+func (ca *testCA) url(format string, args ...any) string {
+	return ca.server.URL + fmt.Sprintf(format, args...)
+}
+
+func (ca *testCA) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", strconv.FormatInt(time.Now().UnixNano(), 36))
+	w.Header().Set("Content-Type", "application/json")
+
+	var request struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(request.Payload)
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	path, id := r.URL.Path, -1
+	if idx := strings.LastIndexByte(path, '/'); idx > 0 {
+		if n, err := strconv.Atoi(path[idx+1:]); err == nil {
+			path, id = path[:idx], n
+		}
+	}
+
+	switch {
+	case path == "/directory":
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"newNonce":   ca.url("/nonce"),
+			"newAccount": ca.url("/account"),
+			"newOrder":   ca.url("/order"),
+			"meta":       map[string]string{"termsOfService": ca.url("/terms")},
+		})
+
+	case path == "/nonce":
+		w.WriteHeader(http.StatusOK)
+
+	case path == "/account":
+		protected, _ := base64.RawURLEncoding.DecodeString(request.Protected)
+		var header struct {
+			JWK struct{ X, Y string } `json:"jwk"`
+		}
+		if err := json.Unmarshal(protected, &header); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		x, _ := base64.RawURLEncoding.DecodeString(header.JWK.X)
+		y, _ := base64.RawURLEncoding.DecodeString(header.JWK.Y)
+		thumbprint, err := acme.JWKThumbprint(&ecdsa.PublicKey{
+			Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ca.thumbprint = thumbprint
+		w.Header().Set("Location", ca.url("/account/1"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"status":"valid"}`))
+
+	case path == "/order" && id == -1:
+		var newOrder struct {
+			Identifiers []acme.AuthzID `json:"identifiers"`
+		}
+		if err := json.Unmarshal(payload, &newOrder); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		order := &testOrder{Status: acme.StatusPending, Identifiers: newOrder.Identifiers, Finalize: ca.url("/finalize/%d", len(ca.orders))}
+		for _, identifier := range newOrder.Identifiers {
+			authzID := len(ca.authzs)
+			ca.authzs = append(ca.authzs, &testAuthz{
+				Status:     acme.StatusPending,
+				Identifier: identifier,
+				Challenges: []testChallenge{{
+					Type:   "tls-alpn-01",
+					URL:    ca.url("/challenge/%d", authzID),
+					Token:  fmt.Sprintf("token-%d", authzID),
+					Status: acme.StatusPending,
+				}},
+			})
+			order.Authorizations = append(order.Authorizations, ca.url("/authz/%d", authzID))
+		}
+		w.Header().Set("Location", ca.url("/order/%d", len(ca.orders)))
+		ca.orders = append(ca.orders, order)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(order)
+
+	case path == "/order" && id < len(ca.orders):
+		w.Header().Set("Location", ca.url("/order/%d", id))
+		_ = json.NewEncoder(w).Encode(ca.orders[id])
+
+	case path == "/authz" && id >= 0 && id < len(ca.authzs):
+		_ = json.NewEncoder(w).Encode(ca.authzs[id])
+
+	case path == "/challenge" && id >= 0 && id < len(ca.authzs):
+		authz := ca.authzs[id]
+		if err := ca.validate(authz.Identifier.Value, authz.Challenges[0].Token); err != nil {
+			authz.Status = acme.StatusInvalid
+		} else {
+			authz.Status = acme.StatusValid
+		}
+		authz.Challenges[0].Status = authz.Status
+		ca.updateOrders()
+		_ = json.NewEncoder(w).Encode(authz.Challenges[0])
+
+	case path == "/finalize" && id >= 0 && id < len(ca.orders):
+		order := ca.orders[id]
+		var finalize struct {
+			CSR string `json:"csr"`
+		}
+		_ = json.Unmarshal(payload, &finalize)
+		csrDER, _ := base64.RawURLEncoding.DecodeString(finalize.CSR)
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if order.Status != acme.StatusReady || err != nil {
+			http.Error(w, "order is not ready", http.StatusForbidden)
+			return
+		}
+		ca.issued++
+		leaf, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(int64(ca.issued + 1)),
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, ca.root, csr.PublicKey, ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		order.chain = [][]byte{leaf, ca.root.Raw}
+		order.Status = acme.StatusValid
+		order.Certificate = ca.url("/certificate/%d", id)
+		w.Header().Set("Location", ca.url("/order/%d", id))
+		_ = json.NewEncoder(w).Encode(order)
+
+	case path == "/certificate" && id >= 0 && id < len(ca.orders):
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		for _, der := range ca.orders[id].chain {
+			_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+		}
+
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+// validate connects to the challenge address like a CA would, expecting the certificate for the challenge.
+func (ca *testCA) validate(domain, token string) error {
+	conn, err := tls.Dial("tcp", ca.challengeAddr, &tls.Config{
+		ServerName:         domain,
+		NextProtos:         []string{acme.ALPNProto},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	if state.NegotiatedProtocol != acme.ALPNProto {
+		return fmt.Errorf("negotiated %q", state.NegotiatedProtocol)
+	}
+	if err := state.PeerCertificates[0].VerifyHostname(domain); err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(token + "." + ca.thumbprint))
+	for _, extension := range state.PeerCertificates[0].Extensions {
+		var value []byte
+		if extension.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}) {
+			if _, err := asn1.Unmarshal(extension.Value, &value); err != nil {
+				return err
+			}
+			if !bytes.Equal(value, digest[:]) {
+				return fmt.Errorf("wrong key authorization")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no acmeIdentifier extension")
+}
+
+func (ca *testCA) updateOrders() {
+	for _, order := range ca.orders {
+		if order.Status != acme.StatusPending {
+			continue
+		}
+		order.Status = acme.StatusReady
+		for _, url := range order.Authorizations {
+			id, _ := strconv.Atoi(url[strings.LastIndexByte(url, '/')+1:])
+			if status := ca.authzs[id].Status; status != acme.StatusValid {
+				order.Status = acme.StatusPending
+				if status == acme.StatusInvalid {
+					order.Status = acme.StatusInvalid
+				}
+				break
+			}
+		}
+	}
+}
+
+// serveTLS accepts connections with the ALPN protocols of a DoH server, returning its address.
+func serveTLS(t *testing.T, service *Service) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetCertificate: service.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     append([]string{"h2", "http/1.1"}, service.NextProtos()...),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestACMEObtainsCertificateThroughTLSALPN(t *testing.T) {
+	ca := newTestCA(t)
+	service, err := NewService(settings.TLSConfig{
+		Enabled: true,
+		ACME: settings.ACMEConfig{
+			Enabled:   true,
+			Domains:   []string{"DNS.example.com.", "doh.example.com"},
+			Directory: ca.url("/directory"),
+			CA:        ca.directoryCA(t),
+		},
+	}, t.TempDir())
+	require.NoError(t, err)
+	require.True(t, service.Available())
+	assert.Equal(t, []string{acme.ALPNProto}, service.NextProtos())
+
+	addr := serveTLS(t, service)
+	ca.mu.Lock()
+	ca.challengeAddr = addr
+	ca.mu.Unlock()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.root)
+	dial := func(serverName string) *x509.Certificate {
+		t.Helper()
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+		})
+		require.NoError(t, err, serverName)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0]
+	}
+
+	cert := dial("dns.example.com")
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "dns.example.com", Roots: roots})
+	require.NoError(t, err)
+
+	// Clients connecting by IP or with a client ID in the name get the certificate of the first domain
+	for _, serverName := range []string{"", "my-phone.dns.example.com"} {
+		fallback := dial(serverName)
+		assert.Equal(t, cert.SerialNumber, fallback.SerialNumber, "server name %q", serverName)
+	}
+
+	doh := dial("doh.example.com")
+	assert.NoError(t, doh.VerifyHostname("doh.example.com"))
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	assert.Equal(t, 2, ca.issued)
+}
+
+func TestACMEDirectoryRequiresTrustedCA(t *testing.T) {
+	ca := newTestCA(t)
+	config := settings.TLSConfig{
+		Enabled: true,
+		ACME:    settings.ACMEConfig{Enabled: true, Domains: []string{"dns.example.com"}, Directory: ca.url("/directory")},
+	}
+
+	// Without the CA the directory's certificate is not trusted
+	service, err := NewService(config, t.TempDir())
+	require.NoError(t, err)
+	_, err = service.GetCertificate(&tls.ClientHelloInfo{ServerName: "dns.example.com"})
+	assert.ErrorContains(t, err, "certificate")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("no certificates"), 0600))
+	config.ACME.CA = empty
+	_, err = NewService(config, t.TempDir())
+	assert.ErrorContains(t, err, "no certificates found")
+
+	config.ACME.CA = filepath.Join(t.TempDir(), "missing.pem")
+	_, err = NewService(config, t.TempDir())
+	assert.ErrorContains(t, err, "failed to read ACME CA")
+}
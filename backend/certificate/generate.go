@@ -0,0 +1,201 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	caFileName     = "ca.pem"
+	caKeyFileName  = "ca-key.pem"
+	certFileName   = "server.pem"
+	keyFileName    = "server-key.pem"
+	caValidity     = 10 * 365 * 24 * time.Hour
+	serverValidity = 397 * 24 * time.Hour
+	renewBefore    = 30 * 24 * time.Hour
+)
+
+// localCertificate is a certificate signed by a CA generated for this installation.
+type localCertificate struct {
+	certFile string
+	keyFile  string
+	caFile   string
+}
+
+// ensureLocalCertificate creates a local CA and a server certificate signed by it in dir, valid for the names
+// and addresses of this host and serverName. Existing files are reused, except for a server certificate close
+// to expiring or not valid for these names, which is replaced.
+func ensureLocalCertificate(dir, serverName string) (localCertificate, error) {
+	local := localCertificate{
+		certFile: filepath.Join(dir, certFileName),
+		keyFile:  filepath.Join(dir, keyFileName),
+		caFile:   filepath.Join(dir, caFileName),
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return local, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	names, ips := localNames(serverName)
+	ca, err := loadOrCreateCA(local.caFile, filepath.Join(dir, caKeyFileName))
+	if err != nil {
+		return local, err
+	}
+
+	if existing, err := tls.LoadX509KeyPair(local.certFile, local.keyFile); err == nil {
+		if time.Until(existing.Leaf.NotAfter) > renewBefore && coversNames(existing.Leaf, names) {
+			return local, nil
+		}
+		log.Info("Local certificate expires %s or does not cover %v, renewing it",
+			existing.Leaf.NotAfter.Format(time.DateOnly), names)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return local, fmt.Errorf("failed to load local certificate: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return local, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	template, err := newTemplate("GoAway DNS", serverValidity)
+	if err != nil {
+		return local, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	template.DNSNames = names
+	template.IPAddresses = ips
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Leaf, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return local, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	if err := writeKeyPair(local.certFile, local.keyFile, der, key); err != nil {
+		return local, err
+	}
+
+	log.Info("Generated a local certificate for %v %v, trust %s on clients to use it", names, ipStrings(ips), local.caFile)
+	return local, nil
+}
+
+func coversNames(cert *x509.Certificate, names []string) bool {
+	for _, name := range names {
+		if wildcard, found := strings.CutPrefix(name, "*."); found {
+			name = "client-id." + wildcard
+		}
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func loadOrCreateCA(certFile, keyFile string) (tls.Certificate, error) {
+	ca, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err == nil {
+		return ca, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return tls.Certificate{}, fmt.Errorf("failed to load local CA: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	template, err := newTemplate("GoAway Local CA", caValidity)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	if err := writeKeyPair(certFile, keyFile, der, key); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	log.Info("Generated local CA %s", certFile)
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"GoAway"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+func writeKeyPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", keyFile, err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", certFile, err)
+	}
+	return nil
+}
+
+// localNames returns the names and addresses clients may use to reach this host.
+func localNames(serverName string) (names []string, ips []net.IP) {
+	names = []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		names = append(names, hostname)
+	}
+	if serverName != "" {
+		// The wildcard covers DoT client IDs, "<client-id>.<serverName>"
+		names = append(names, serverName, "*."+serverName)
+	}
+
+	ips = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return names, ips
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return names, ips
+}
+
+func ipStrings(ips []net.IP) []string {
+	values := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		values = append(values, ip.String())
+	}
+	return values
+}
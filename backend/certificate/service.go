@@ -0,0 +1,187 @@
+package certificate
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"goaway/backend/logging"
+	"goaway/backend/settings"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+var log = logging.GetLogger()
+
+const pollInterval = 30 * time.Second
+
+// ErrNoLocalCA is returned when the certificate was not issued by a locally generated CA.
+var ErrNoLocalCA = errors.New("certificate was not issued by a local CA")
+
+// Service provides the certificate used by DoT, DoH and DoQ. It is loaded from the configured files,
+// generated along with a local CA when TLS is enabled without files, or obtained from an ACME CA.
+// Servers use GetCertificate, so renewed certificates are picked up without a restart.
+type Service struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	localDir   string
+	serverName string
+	acme       *acmeManager
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewService creates the certificate service for config. Generated certificates are stored in dir.
+func NewService(config settings.TLSConfig, dir string) (*Service, error) {
+	service := &Service{}
+	if !config.Enabled {
+		return service, nil
+	}
+
+	if config.ACME.Enabled {
+		manager, err := newACMEManager(config.ACME, dir)
+		if err != nil {
+			return nil, err
+		}
+		service.acme = manager
+		return service, nil
+	}
+
+	service.certFile, service.keyFile = config.Cert, config.Key
+	if service.certFile == "" || service.keyFile == "" {
+		local, err := ensureLocalCertificate(dir, config.ServerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate local certificate: %w", err)
+		}
+		service.certFile, service.keyFile, service.caFile = local.certFile, local.keyFile, local.caFile
+		service.localDir, service.serverName = dir, config.ServerName
+	}
+
+	if _, err := service.reload(); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// Available reports whether a certificate is configured, and the servers requiring one can be started.
+func (s *Service) Available() bool {
+	if s.acme != nil {
+		return true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert != nil
+}
+
+// GetCertificate returns the current certificate, for use as tls.Config.GetCertificate.
+func (s *Service) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if s.acme != nil {
+		return s.acme.GetCertificate(hello)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return nil, errors.New("no certificate is configured")
+	}
+	return s.cert, nil
+}
+
+// NextProtos returns the ALPN protocols to offer besides those of the server, answering ACME
+// TLS-ALPN-01 challenges when certificates are obtained from an ACME CA.
+func (s *Service) NextProtos() []string {
+	if s.acme != nil {
+		return []string{acme.ALPNProto}
+	}
+	return nil
+}
+
+// CACertificate returns the PEM encoded local CA, for clients to trust the generated certificate.
+func (s *Service) CACertificate() ([]byte, error) {
+	if s.caFile == "" {
+		return nil, ErrNoLocalCA
+	}
+	return os.ReadFile(s.caFile)
+}
+
+// Watch polls the certificate and key files and reloads them when they change.
+func (s *Service) Watch(ctx context.Context) {
+	if s.certFile == "" {
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.localDir != "" {
+				// Replaces the local certificate once it is close to expiring
+				if _, err := ensureLocalCertificate(s.localDir, s.serverName); err != nil {
+					log.Warning("Failed to renew local certificate: %v", err)
+				}
+			}
+
+			changed, err := s.reload()
+			if err != nil {
+				log.Warning("Failed to reload TLS certificate, keeping the current one: %v", err)
+				continue
+			}
+			if changed {
+				log.Info("Reloaded TLS certificate from %s", s.certFile)
+			}
+		}
+	}
+}
+
+// reload loads the certificate and key when either file changed since they were last loaded.
+func (s *Service) reload() (bool, error) {
+	modTime, err := latestModTime(s.certFile, s.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	unchanged := s.cert != nil && modTime.Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	if time.Now().After(cert.Leaf.NotAfter) {
+		log.Warning("TLS certificate %s expired on %s", s.certFile, cert.Leaf.NotAfter.Format(time.DateOnly))
+	}
+
+	s.mu.Lock()
+	s.cert, s.modTime = &cert, modTime
+	s.mu.Unlock()
+	return true, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
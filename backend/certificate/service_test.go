@@ -0,0 +1,94 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"goaway/backend/settings"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedCertificateIsTrustedThroughLocalCA(t *testing.T) {
+	dir := t.TempDir()
+	service, err := NewService(settings.TLSConfig{Enabled: true, ServerName: "dns.example.com"}, dir)
+	require.NoError(t, err)
+	require.True(t, service.Available())
+
+	cert, err := service.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+
+	caPEM, err := service.CACertificate()
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+
+	for _, name := range []string{"dns.example.com", "my-phone.dns.example.com", "127.0.0.1"} {
+		_, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+		assert.NoError(t, err, name)
+	}
+
+	// A second start reuses the generated files
+	again, err := NewService(settings.TLSConfig{Enabled: true, ServerName: "dns.example.com"}, dir)
+	require.NoError(t, err)
+	reused, err := again.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, cert.Leaf.SerialNumber, reused.Leaf.SerialNumber)
+}
+
+func TestReloadPicksUpRenewedCertificate(t *testing.T) {
+	generated := t.TempDir()
+	local, err := ensureLocalCertificate(generated, "old.example.com")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	copyFile(t, local.certFile, certFile)
+	copyFile(t, local.keyFile, keyFile)
+
+	service, err := NewService(settings.TLSConfig{Enabled: true, Cert: certFile, Key: keyFile}, t.TempDir())
+	require.NoError(t, err)
+	_, err = service.CACertificate()
+	assert.ErrorIs(t, err, ErrNoLocalCA)
+
+	changed, err := service.reload()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	renewed, err := ensureLocalCertificate(generated, "new.example.com")
+	require.NoError(t, err)
+	copyFile(t, renewed.certFile, certFile)
+	copyFile(t, renewed.keyFile, keyFile)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	changed, err = service.reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	cert, err := service.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.NoError(t, cert.Leaf.VerifyHostname("new.example.com"))
+}
+
+func TestDisabledTLSHasNoCertificate(t *testing.T) {
+	service, err := NewService(settings.TLSConfig{}, t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, service.Available())
+}
+
+func TestACMERequiresDomains(t *testing.T) {
+	_, err := NewService(settings.TLSConfig{Enabled: true, ACME: settings.ACMEConfig{Enabled: true}}, t.TempDir())
+	assert.Error(t, err)
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(dst, data, 0600))
+}
@@ -44,10 +44,14 @@ func AutoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&Source{},
 		&Blacklist{},
+		&SourceRevision{},
+		&BlockedBundle{},
 		&Whitelist{},
+		&Resolution{},
 		&RequestLog{},
 		&RequestLogIP{},
 		&MacAddress{},
+		&ClientIdentifier{},
 		&User{},
 		&APIKey{},
 		&Notification{},
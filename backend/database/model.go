@@ -22,18 +22,53 @@ type Blacklist struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// SourceRevision is a stored snapshot of a blocklist's contents, used to inspect and roll back list updates.
+// Revisions are keyed by name and URL rather than source ID as list updates recreate the source row.
+type SourceRevision struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SourceName   string    `gorm:"not null;index:idx_source_revision,priority:1" json:"sourceName"`
+	SourceURL    string    `gorm:"not null;index:idx_source_revision,priority:2" json:"sourceURL"`
+	Checksum     string    `gorm:"type:varchar(64);not null" json:"checksum"`
+	Origin       string    `gorm:"type:varchar(20);not null" json:"origin"`
+	Domains      []byte    `json:"-"`
+	DomainCount  int       `json:"domainCount"`
+	AddedCount   int       `json:"addedCount"`
+	RemovedCount int       `json:"removedCount"`
+	Reverted     bool      `gorm:"default:false" json:"reverted"`
+	CreatedAt    time.Time `gorm:"index" json:"createdAt"`
+}
+
+// BlockedBundle marks a built-in service bundle as blocked.
+type BlockedBundle struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 type Whitelist struct {
 	Domain    string    `gorm:"primaryKey" json:"domain" validate:"required,fqdn"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// Resolution is a local DNS record answered without querying upstream.
+// Value holds the record data in zone file presentation format, e.g. "10 mail.example.com." for MX.
+type Resolution struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Domain    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_resolution_record,priority:1" json:"domain"`
+	Type      string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_resolution_record,priority:2" json:"type"`
+	Value     string    `gorm:"not null;uniqueIndex:idx_resolution_record,priority:3" json:"value"`
+	TTL       uint32    `json:"ttl"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 type RequestLog struct {
 	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
 	Timestamp         time.Time      `gorm:"not null;index:idx_timestamp_response_size,priority:1;index:idx_timestamp_covering,priority:1" json:"timestamp"`
 	Domain            string         `gorm:"type:varchar(255);not null;index:idx_domain_timestamp,priority:1;index:idx_client_ip_domain,priority:2" json:"domain" validate:"required"`
 	ClientIP          string         `gorm:"type:varchar(45);not null;index:idx_client_ip;index:idx_client_ip_domain,priority:1;index:idx_client_ip_client_name,priority:1" json:"clientIP" validate:"required,ip"`
 	ClientName        string         `gorm:"type:varchar(255);index:idx_client_ip_client_name,priority:2" json:"clientName"`
+	ClientMAC         string         `gorm:"type:varchar(17);not null;default:'';index:idx_client_mac" json:"clientMAC"`
+	ClientID          string         `gorm:"type:varchar(63);not null;default:'';index:idx_client_id" json:"clientID"`
 	QueryType         string         `gorm:"type:varchar(10);index:idx_query_type" json:"queryType"`
 	Status            string         `gorm:"type:varchar(20)" json:"status"`
 	Protocol          string         `gorm:"type:varchar(10)" json:"protocol"`
@@ -54,10 +89,21 @@ type RequestLogIP struct {
 	CreatedAt    time.Time  `json:"createdAt"`
 }
 
+// MacAddress is a device on the network. Name and Bypass follow the device when its IP changes.
 type MacAddress struct {
 	MAC       string    `gorm:"primaryKey;index:idx_mac_lookup" json:"mac" validate:"required,mac"`
 	IP        string    `gorm:"index:idx_ip_lookup" json:"ip" validate:"required,ip"`
 	Vendor    string    `json:"vendor"`
+	Name      string    `json:"name"`
+	Bypass    bool      `gorm:"default:false" json:"bypass"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ClientIdentifier holds the settings of a DoH or DoT client identified by its client ID.
+type ClientIdentifier struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
 	Bypass    bool      `gorm:"default:false" json:"bypass"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
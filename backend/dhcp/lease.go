@@ -0,0 +1,256 @@
+package dhcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatDnsmasq = "dnsmasq"
+	FormatISC     = "isc"
+	FormatKea     = "kea"
+	FormatOdhcpd  = "odhcpd"
+)
+
+// Lease is a single address handed out by a DHCP server.
+// A zero Expires means the lease never expires.
+type Lease struct {
+	IP       netip.Addr `json:"ip"`
+	MAC      string     `json:"mac"`
+	Hostname string     `json:"hostname"`
+	Expires  time.Time  `json:"expires"`
+}
+
+func (l Lease) expired(now time.Time) bool {
+	return !l.Expires.IsZero() && l.Expires.Before(now)
+}
+
+func parseLeases(format string, r io.Reader) ([]Lease, error) {
+	switch format {
+	case FormatDnsmasq:
+		return parseDnsmasq(r)
+	case FormatISC:
+		return parseISC(r)
+	case FormatKea:
+		return parseKea(r)
+	case FormatOdhcpd:
+		return parseOdhcpd(r)
+	default:
+		return nil, fmt.Errorf("unknown lease file format '%s'", format)
+	}
+}
+
+// parseDnsmasq reads dnsmasq.leases, where each line is "<expiry> <mac> <ip> <hostname> <client-id>".
+// DHCPv6 leases follow a "duid" line and carry an IAID instead of a MAC.
+func parseDnsmasq(r io.Reader) ([]Lease, error) {
+	var leases []Lease
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "duid" {
+			continue
+		}
+
+		ip, err := netip.ParseAddr(fields[2])
+		if err != nil {
+			continue
+		}
+
+		lease := Lease{
+			IP:       ip,
+			MAC:      normalizeMAC(fields[1]),
+			Hostname: normalizeHostname(fields[3]),
+		}
+		if expiry, err := strconv.ParseInt(fields[0], 10, 64); err == nil && expiry > 0 {
+			lease.Expires = time.Unix(expiry, 0)
+		}
+		leases = append(leases, lease)
+	}
+
+	return leases, scanner.Err()
+}
+
+// parseISC reads dhcpd.leases from ISC dhcpd. The file is append-only, so later declarations for
+// the same address replace earlier ones and leases that are no longer active are dropped.
+func parseISC(r io.Reader) ([]Lease, error) {
+	var (
+		current *Lease
+		active  bool
+		byIP    = map[netip.Addr]Lease{}
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, ";"))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case fields[0] == "lease" && len(fields) >= 2:
+			current = nil
+			if ip, err := netip.ParseAddr(fields[1]); err == nil {
+				current = &Lease{IP: ip}
+				active = true
+			}
+		case current == nil:
+			continue
+		case line == "}":
+			if active {
+				byIP[current.IP] = *current
+			} else {
+				delete(byIP, current.IP)
+			}
+			current = nil
+		case fields[0] == "binding" && len(fields) >= 3 && fields[1] == "state":
+			active = fields[2] == "active"
+		case fields[0] == "hardware" && len(fields) >= 3:
+			current.MAC = normalizeMAC(fields[2])
+		case fields[0] == "client-hostname" && len(fields) >= 2:
+			current.Hostname = normalizeHostname(strings.Trim(strings.Join(fields[1:], " "), `"`))
+		case fields[0] == "ends" && len(fields) >= 4:
+			if ends, err := time.Parse("2006/01/02 15:04:05", fields[2]+" "+fields[3]); err == nil {
+				current.Expires = ends
+			}
+		}
+	}
+
+	leases := make([]Lease, 0, len(byIP))
+	for _, lease := range byIP {
+		leases = append(leases, lease)
+	}
+	return leases, scanner.Err()
+}
+
+// parseKea reads the JSON returned by the Kea "lease4-get-all" and "lease6-get-all" commands,
+// either as a single response or as the list of responses the control agent returns.
+func parseKea(r io.Reader) ([]Lease, error) {
+	type keaResponse struct {
+		Arguments struct {
+			Leases []struct {
+				IPAddress string `json:"ip-address"`
+				HWAddress string `json:"hw-address"`
+				Hostname  string `json:"hostname"`
+				CLTT      int64  `json:"cltt"`
+				ValidLft  int64  `json:"valid-lft"`
+			} `json:"leases"`
+		} `json:"arguments"`
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []keaResponse
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &responses)
+	} else {
+		var response keaResponse
+		err = json.Unmarshal(data, &response)
+		responses = append(responses, response)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kea lease JSON: %w", err)
+	}
+
+	var leases []Lease
+	for _, response := range responses {
+		for _, l := range response.Arguments.Leases {
+			ip, err := netip.ParseAddr(l.IPAddress)
+			if err != nil {
+				continue
+			}
+
+			lease := Lease{
+				IP:       ip,
+				MAC:      normalizeMAC(l.HWAddress),
+				Hostname: normalizeHostname(l.Hostname),
+			}
+			if l.CLTT > 0 && l.ValidLft > 0 && l.ValidLft != 0xffffffff {
+				lease.Expires = time.Unix(l.CLTT+l.ValidLft, 0)
+			}
+			leases = append(leases, lease)
+		}
+	}
+
+	return leases, nil
+}
+
+// parseOdhcpd reads the odhcpd lease file used on OpenWrt. Every lease is a line formatted as
+// "# <interface> <duid|mac> <iaid|ipv4> <hostname> <valid-until> <assigned> <length> <address/len>..."
+func parseOdhcpd(r io.Reader) ([]Lease, error) {
+	var leases []Lease
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 || fields[0] != "#" {
+			continue
+		}
+
+		var expires time.Time
+		if validUntil, err := strconv.ParseInt(fields[5], 10, 64); err == nil && validUntil > 0 {
+			expires = time.Unix(validUntil, 0)
+		}
+
+		mac := ""
+		if fields[3] == "ipv4" {
+			mac = normalizeMAC(fields[2])
+		}
+
+		for _, address := range fields[8:] {
+			address, _, _ = strings.Cut(address, "/")
+			ip, err := netip.ParseAddr(address)
+			if err != nil {
+				continue
+			}
+			leases = append(leases, Lease{
+				IP:       ip,
+				MAC:      mac,
+				Hostname: normalizeHostname(fields[4]),
+				Expires:  expires,
+			})
+		}
+	}
+
+	return leases, scanner.Err()
+}
+
+func normalizeMAC(mac string) string {
+	if len(mac) == 12 && !strings.ContainsAny(mac, ":-") {
+		var sb strings.Builder
+		for i := 0; i < len(mac); i += 2 {
+			if i > 0 {
+				sb.WriteByte(':')
+			}
+			sb.WriteString(mac[i : i+2])
+		}
+		mac = sb.String()
+	}
+
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return ""
+	}
+	return hw.String()
+}
+
+func normalizeHostname(hostname string) string {
+	if hostname == "*" || hostname == "-" {
+		return ""
+	}
+	return strings.TrimSuffix(hostname, ".")
+}
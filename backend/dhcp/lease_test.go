@@ -0,0 +1,112 @@
+package dhcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLeases(t *testing.T) {
+	tests := []struct {
+		format   string
+		content  string
+		expected map[string]Lease
+	}{
+		{
+			format: FormatDnsmasq,
+			content: `1700000000 aa:bb:cc:dd:ee:01 192.168.1.10 laptop 01:aa:bb:cc:dd:ee:01
+0 aa:bb:cc:dd:ee:02 192.168.1.11 * *
+duid 00:01:00:01:2c:1f:6a:5e:aa:bb:cc:dd:ee:01
+1700000000 1234 fd00::10 laptop 00:01:00:01
+`,
+			expected: map[string]Lease{
+				"192.168.1.10": {MAC: "aa:bb:cc:dd:ee:01", Hostname: "laptop"},
+				"192.168.1.11": {MAC: "aa:bb:cc:dd:ee:02"},
+				"fd00::10":     {Hostname: "laptop"},
+			},
+		},
+		{
+			format: FormatISC,
+			content: `# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.20 {
+  starts 4 2024/01/01 10:00:00;
+  ends 4 2024/01/01 22:00:00;
+  binding state active;
+  hardware ethernet AA:BB:CC:DD:EE:03;
+  client-hostname "printer";
+}
+lease 192.168.1.21 {
+  binding state active;
+  hardware ethernet aa:bb:cc:dd:ee:04;
+  client-hostname "old-name";
+}
+lease 192.168.1.21 {
+  binding state active;
+  hardware ethernet aa:bb:cc:dd:ee:04;
+  client-hostname "new-name";
+}
+lease 192.168.1.22 {
+  binding state free;
+  hardware ethernet aa:bb:cc:dd:ee:05;
+}
+`,
+			expected: map[string]Lease{
+				"192.168.1.20": {MAC: "aa:bb:cc:dd:ee:03", Hostname: "printer"},
+				"192.168.1.21": {MAC: "aa:bb:cc:dd:ee:04", Hostname: "new-name"},
+			},
+		},
+		{
+			format: FormatISC,
+			content: `;
+lease 192.168.1.23 {
+  ;
+  binding state active;
+  hardware ethernet aa:bb:cc:dd:ee:08;
+}
+`,
+			expected: map[string]Lease{
+				"192.168.1.23": {MAC: "aa:bb:cc:dd:ee:08"},
+			},
+		},
+		{
+			format: FormatKea,
+			content: `[{"result": 0, "arguments": {"leases": [
+  {"ip-address": "192.168.1.30", "hw-address": "aa:bb:cc:dd:ee:06", "hostname": "tv.", "cltt": 1700000000, "valid-lft": 3600}
+]}}]`,
+			expected: map[string]Lease{
+				"192.168.1.30": {MAC: "aa:bb:cc:dd:ee:06", Hostname: "tv"},
+			},
+		},
+		{
+			format: FormatOdhcpd,
+			content: `# br-lan aabbccddee07 ipv4 phone 1700000000 a 32 192.168.1.40/32
+# br-lan 000100012c1f6a5e 1 phone 1700000000 c 128 fd00::40/128
+`,
+			expected: map[string]Lease{
+				"192.168.1.40": {MAC: "aa:bb:cc:dd:ee:07", Hostname: "phone"},
+				"fd00::40":     {Hostname: "phone"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			leases, err := parseLeases(tt.format, strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if len(leases) != len(tt.expected) {
+				t.Fatalf("got %d leases, want %d: %+v", len(leases), len(tt.expected), leases)
+			}
+			for _, lease := range leases {
+				expected, found := tt.expected[lease.IP.String()]
+				if !found {
+					t.Errorf("unexpected lease for %s", lease.IP)
+					continue
+				}
+				if lease.MAC != expected.MAC || lease.Hostname != expected.Hostname {
+					t.Errorf("lease %s = %s/%s, want %s/%s", lease.IP, lease.MAC, lease.Hostname, expected.MAC, expected.Hostname)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package dhcp
+
+import (
+	"context"
+	"goaway/backend/logging"
+	"goaway/backend/settings"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+)
+
+var log = logging.GetLogger()
+
+const pollInterval = 10 * time.Second
+
+type leaseFile struct {
+	config  settings.LeaseFileConfig
+	modTime time.Time
+	size    int64
+	leases  []Lease
+}
+
+// Service reads the lease files of local DHCP servers so clients can be named without probing them.
+type Service struct {
+	files []*leaseFile
+
+	mu     sync.RWMutex
+	leases map[netip.Addr]Lease
+}
+
+func NewService(files []settings.LeaseFileConfig) *Service {
+	service := &Service{leases: map[netip.Addr]Lease{}}
+	for _, file := range files {
+		if file.Path == "" {
+			continue
+		}
+		service.files = append(service.files, &leaseFile{config: file})
+	}
+
+	service.refresh()
+	return service
+}
+
+// Lookup returns the active lease for ip.
+func (s *Service) Lookup(ip netip.Addr) (Lease, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lease, found := s.leases[ip]
+	if !found || lease.expired(time.Now()) {
+		return Lease{}, false
+	}
+	return lease, true
+}
+
+// Leases returns all active leases.
+func (s *Service) Leases() []Lease {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	leases := make([]Lease, 0, len(s.leases))
+	for _, lease := range s.leases {
+		if !lease.expired(now) {
+			leases = append(leases, lease)
+		}
+	}
+	return leases
+}
+
+// Watch polls the lease files for changes and calls onChange after they have been re-read.
+func (s *Service) Watch(ctx context.Context, onChange func()) {
+	if len(s.files) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.refresh() {
+				onChange()
+			}
+		}
+	}
+}
+
+// refresh re-reads lease files that changed since the last read and reports whether any did.
+func (s *Service) refresh() bool {
+	changed := false
+	for _, file := range s.files {
+		info, err := os.Stat(file.config.Path)
+		if err != nil {
+			if file.leases != nil {
+				log.Warning("Lease file %s is no longer readable: %v", file.config.Path, err)
+				file.leases, file.modTime, file.size = nil, time.Time{}, 0
+				changed = true
+			}
+			continue
+		}
+		if info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+			continue
+		}
+
+		leases, err := readLeaseFile(file.config)
+		if err != nil {
+			log.Warning("Failed to read lease file %s: %v", file.config.Path, err)
+			continue
+		}
+
+		log.Debug("Read %d leases from %s", len(leases), file.config.Path)
+		file.leases, file.modTime, file.size = leases, info.ModTime(), info.Size()
+		changed = true
+	}
+
+	if changed {
+		leases := make(map[netip.Addr]Lease)
+		for _, file := range s.files {
+			for _, lease := range file.leases {
+				leases[lease.IP] = lease
+			}
+		}
+
+		s.mu.Lock()
+		s.leases = leases
+		s.mu.Unlock()
+	}
+
+	return changed
+}
+
+func readLeaseFile(config settings.LeaseFileConfig) ([]Lease, error) {
+	file, err := os.Open(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return parseLeases(config.Format, file)
+}
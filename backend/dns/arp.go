@@ -2,11 +2,8 @@ package arp
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"goaway/backend/logging"
-	"io"
-	"net/http"
 	"net/netip"
 	"os/exec"
 	"runtime"
@@ -17,37 +14,12 @@ import (
 
 var log = logging.GetLogger()
 
-type vendorResponse struct {
-	Company string `json:"company"`
-	Success bool   `json:"success"`
-	Found   bool   `json:"found"`
-}
-
 type Cache struct {
 	table map[string]string
 	mu    sync.RWMutex
 }
 
-type vendorCacheEntry struct {
-	vendor    string
-	err       error
-	timestamp time.Time
-}
-
-type VendorCache struct {
-	entries map[string]*vendorCacheEntry
-	mu      sync.RWMutex
-	ttl     time.Duration
-}
-
-var (
-	cache       = &Cache{table: make(map[string]string)}
-	vendorCache = &VendorCache{
-		entries: make(map[string]*vendorCacheEntry),
-		ttl:     60 * time.Second,
-	}
-	httpClient = &http.Client{Timeout: 5 * time.Second}
-)
+var cache = &Cache{table: make(map[string]string)}
 
 func ProcessARPTable() {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -61,23 +33,27 @@ func ProcessARPTable() {
 	}
 }
 
-func CleanVendorResponseCache() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-	for range ticker.C {
-		vendorCache.cleanup()
+func updateARPTable() {
+	newTable, err := readNeighbours()
+	if err != nil {
+		log.Warning("Error reading neighbour table: %v", err)
+		return
 	}
+
+	cache.mu.Lock()
+	cache.table = newTable
+	cache.mu.Unlock()
 }
 
-func updateARPTable() {
+// readARPCommand parses the output of 'arp -a', used where the neighbour table can not be read natively.
+func readARPCommand() (map[string]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "arp", "-a")
 	out, err := cmd.Output()
 	if err != nil {
-		log.Warning("Error running ARP command: %v", err)
-		return
+		return nil, fmt.Errorf("error running ARP command: %w", err)
 	}
 
 	newTable := make(map[string]string)
@@ -88,9 +64,7 @@ func updateARPTable() {
 		parseWindowsARP(string(out), newTable)
 	}
 
-	cache.mu.Lock()
-	cache.table = newTable
-	cache.mu.Unlock()
+	return newTable, nil
 }
 
 func parseWindowsARP(output string, table map[string]string) {
@@ -146,109 +120,9 @@ func GetMacAddress(ip netip.Addr) string {
 	return "unknown"
 }
 
-func GetMacVendor(mac string) (string, error) {
-	if mac == "" || mac == "unknown" {
-		return "", fmt.Errorf("invalid MAC address")
-	}
-
-	mac = strings.ReplaceAll(mac, ":", "")
-	mac = strings.ReplaceAll(mac, "-", "")
-	mac = strings.ToLower(mac)
-
-	if vendor, err, found := vendorCache.get(mac); found {
-		return vendor, err
-	}
-
-	url := fmt.Sprintf("https://api.maclookup.app/v2/macs/%s", mac)
-	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
-	if err != nil {
-		reqErr := fmt.Errorf("failed to create request: %w", err)
-		vendorCache.set(mac, "", reqErr)
-		return "", reqErr
-	}
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		apiErr := fmt.Errorf("failed to fetch MAC vendor: %w", err)
-		vendorCache.set(mac, "", apiErr)
-		return "", apiErr
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		statusErr := fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		vendorCache.set(mac, "", statusErr)
-		return "", statusErr
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		readErr := fmt.Errorf("failed to read response body: %w", err)
-		vendorCache.set(mac, "", readErr)
-		return "", readErr
-	}
-
-	var result vendorResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		unmarshalErr := fmt.Errorf("failed to unmarshal response: %w", err)
-		vendorCache.set(mac, "", unmarshalErr)
-		return "", unmarshalErr
-	}
-
-	if result.Found {
-		vendorCache.set(mac, result.Company, nil)
-		return result.Company, nil
-	}
-
-	notFoundErr := fmt.Errorf("vendor not found for mac %s", mac)
-	vendorCache.set(mac, "", notFoundErr)
-	return "", notFoundErr
-}
-
 func isValidMAC(mac string) bool {
 	cleanMAC := strings.ReplaceAll(mac, ":", "")
 	cleanMAC = strings.ReplaceAll(cleanMAC, "-", "")
 
 	return len(cleanMAC) == 12 && cleanMAC != "000000000000"
 }
-
-func (vc *VendorCache) get(mac string) (string, error, bool) {
-	vc.mu.RLock()
-	defer vc.mu.RUnlock()
-
-	entry, exists := vc.entries[mac]
-	if !exists {
-		return "", nil, false
-	}
-
-	if time.Since(entry.timestamp) > vc.ttl {
-		return "", nil, false
-	}
-
-	return entry.vendor, entry.err, true
-}
-
-func (vc *VendorCache) set(mac, vendor string, err error) {
-	vc.mu.Lock()
-	defer vc.mu.Unlock()
-
-	vc.entries[mac] = &vendorCacheEntry{
-		vendor:    vendor,
-		err:       err,
-		timestamp: time.Now(),
-	}
-}
-
-func (vc *VendorCache) cleanup() {
-	vc.mu.Lock()
-	defer vc.mu.Unlock()
-
-	now := time.Now()
-	for mac, entry := range vc.entries {
-		if now.Sub(entry.timestamp) > vc.ttl {
-			delete(vc.entries, mac)
-		}
-	}
-}
@@ -0,0 +1,141 @@
+package arp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"syscall"
+)
+
+const (
+	// Neighbour attributes and states from linux/neighbour.h
+	ndaDst        = 1
+	ndaLLAddr     = 2
+	nudIncomplete = 0x01
+	nudFailed     = 0x20
+
+	// Size of struct ndmsg
+	ndMsgLen = 12
+)
+
+// readNeighbours reads the kernel neighbour table through netlink, which holds both ARP (IPv4) and
+// NDP (IPv6) entries. /proc/net/arp is read as well, covering IPv4 where netlink is not permitted.
+func readNeighbours() (map[string]string, error) {
+	table := make(map[string]string)
+
+	procErr := readProcARP(table)
+	netlinkErr := readNetlinkNeighbours(table)
+	if procErr != nil && netlinkErr != nil {
+		return nil, fmt.Errorf("netlink: %v, /proc/net/arp: %v", netlinkErr, procErr)
+	}
+
+	return table, nil
+}
+
+func readProcARP(table map[string]string) error {
+	file, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return parseProcARP(file, table)
+}
+
+// parseProcARP reads the format of /proc/net/arp:
+// IP address       HW type     Flags       HW address            Mask     Device
+// 192.168.1.10     0x1         0x2         aa:bb:cc:dd:ee:ff     *        eth0
+func parseProcARP(r io.Reader, table map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] == "0x0" {
+			continue
+		}
+
+		mac := strings.ToLower(fields[3])
+		if _, err := netip.ParseAddr(fields[0]); err == nil && isValidMAC(mac) {
+			table[fields[0]] = mac
+		}
+	}
+	return scanner.Err()
+}
+
+func readNetlinkNeighbours(table map[string]string) error {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return err
+	}
+
+	messages, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if msg.Header.Type != syscall.RTM_NEWNEIGH {
+			continue
+		}
+		if ip, mac, ok := parseNeighbourMessage(msg.Data); ok {
+			table[ip.String()] = mac
+		}
+	}
+	return nil
+}
+
+// parseNeighbourMessage reads a struct ndmsg followed by its route attributes.
+func parseNeighbourMessage(data []byte) (netip.Addr, string, bool) {
+	if len(data) < ndMsgLen {
+		return netip.Addr{}, "", false
+	}
+
+	state := binary.NativeEndian.Uint16(data[8:10])
+	if state&(nudIncomplete|nudFailed) != 0 {
+		return netip.Addr{}, "", false
+	}
+
+	var (
+		ip  netip.Addr
+		mac string
+	)
+	attrs := data[ndMsgLen:]
+	for len(attrs) >= syscall.SizeofRtAttr {
+		attrLen := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		attrType := binary.NativeEndian.Uint16(attrs[2:4])
+		if attrLen < syscall.SizeofRtAttr || attrLen > len(attrs) {
+			break
+		}
+		value := attrs[syscall.SizeofRtAttr:attrLen]
+
+		switch attrType {
+		case ndaDst:
+			if addr, ok := netip.AddrFromSlice(value); ok {
+				ip = addr.Unmap()
+			}
+		case ndaLLAddr:
+			if len(value) == 6 {
+				mac = net.HardwareAddr(value).String()
+			}
+		}
+
+		// Attributes are aligned to 4 bytes
+		next := (attrLen + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+
+	if !ip.IsValid() || !isValidMAC(mac) {
+		return netip.Addr{}, "", false
+	}
+	return ip, mac, true
+}
@@ -0,0 +1,50 @@
+package arp
+
+import (
+	"encoding/binary"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestParseProcARP(t *testing.T) {
+	input := `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.10     0x1         0x2         AA:BB:CC:DD:EE:FF     *        eth0
+192.168.1.11     0x1         0x0         00:00:00:00:00:00     *        eth0
+`
+	table := map[string]string{}
+	if err := parseProcARP(strings.NewReader(input), table); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(table) != 1 || table["192.168.1.10"] != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("unexpected table %v", table)
+	}
+}
+
+func TestParseNeighbourMessage(t *testing.T) {
+	attr := func(attrType uint16, value []byte) []byte {
+		length := syscall.SizeofRtAttr + len(value)
+		b := make([]byte, (length+3)&^3)
+		binary.NativeEndian.PutUint16(b[0:2], uint16(length))
+		binary.NativeEndian.PutUint16(b[2:4], attrType)
+		copy(b[4:], value)
+		return b
+	}
+
+	msg := make([]byte, ndMsgLen)
+	msg[0] = syscall.AF_INET6
+	binary.NativeEndian.PutUint16(msg[8:10], 0x02) // NUD_REACHABLE
+	msg = append(msg, attr(ndaDst, []byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1})...)
+	msg = append(msg, attr(ndaLLAddr, []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})...)
+
+	ip, mac, ok := parseNeighbourMessage(msg)
+	if !ok || ip.String() != "fe80::1" || mac != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("got %v %s %v", ip, mac, ok)
+	}
+
+	binary.NativeEndian.PutUint16(msg[8:10], nudFailed)
+	if _, _, ok := parseNeighbourMessage(msg); ok {
+		t.Fatal("failed neighbour should be skipped")
+	}
+}
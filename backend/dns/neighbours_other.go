@@ -0,0 +1,7 @@
+//go:build !linux
+
+package arp
+
+func readNeighbours() (map[string]string, error) {
+	return readARPCommand()
+}
@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultIPv4Prefix = 32
+	defaultIPv6Prefix = 56
+
+	cleanupInterval = time.Minute
+
+	// Bounds the number of tracked buckets, as UDP source addresses can be spoofed
+	maxBuckets = 100000
+)
+
+type bucket struct {
+	tokens  float64
+	last    time.Time
+	limited uint64
+}
+
+// buckets holds a token bucket per key, refilled at rate tokens per second up to burst.
+type buckets[K comparable] struct {
+	rate  float64
+	burst float64
+
+	mu          sync.Mutex
+	entries     map[K]*bucket
+	lastCleanup time.Time
+}
+
+func newBuckets[K comparable](rate float64, burst int) *buckets[K] {
+	if burst < 1 {
+		burst = max(1, int(rate))
+	}
+	return &buckets[K]{
+		rate:    rate,
+		burst:   float64(burst),
+		entries: map[K]*bucket{},
+	}
+}
+
+// take removes a token from the bucket of key. It reports whether one was available, and how many times
+// the bucket ran out since it was created.
+func (b *buckets[K]) take(key K, now time.Time) (bool, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Sub(b.lastCleanup) > cleanupInterval {
+		b.cleanup(now)
+	}
+
+	entry, found := b.entries[key]
+	if !found {
+		if len(b.entries) >= maxBuckets {
+			return true, 0
+		}
+		entry = &bucket{tokens: b.burst, last: now}
+		b.entries[key] = entry
+	}
+
+	entry.tokens = min(b.burst, entry.tokens+now.Sub(entry.last).Seconds()*b.rate)
+	entry.last = now
+	if entry.tokens < 1 {
+		entry.limited++
+		return false, entry.limited
+	}
+
+	entry.tokens--
+	return true, entry.limited
+}
+
+// cleanup removes buckets which have been refilled completely, as they behave like new ones.
+func (b *buckets[K]) cleanup(now time.Time) {
+	for key, entry := range b.entries {
+		if entry.tokens+now.Sub(entry.last).Seconds()*b.rate >= b.burst {
+			delete(b.entries, key)
+		}
+	}
+	b.lastCleanup = now
+}
+
+// subnets maps addresses to the subnet they are limited as.
+type subnets struct {
+	ipv4Bits int
+	ipv6Bits int
+}
+
+func newSubnets(ipv4Prefix, ipv6Prefix int) subnets {
+	if ipv4Prefix <= 0 || ipv4Prefix > 32 {
+		ipv4Prefix = defaultIPv4Prefix
+	}
+	if ipv6Prefix <= 0 || ipv6Prefix > 128 {
+		ipv6Prefix = defaultIPv6Prefix
+	}
+	return subnets{ipv4Bits: ipv4Prefix, ipv6Bits: ipv6Prefix}
+}
+
+func (s subnets) of(ip netip.Addr) netip.Prefix {
+	ip = ip.Unmap()
+	bits := s.ipv6Bits
+	if ip.Is4() {
+		bits = s.ipv4Bits
+	}
+	prefix, _ := ip.Prefix(bits)
+	return prefix
+}
+
+// Limiter limits the queries of each client subnet with a token bucket.
+type Limiter struct {
+	subnets subnets
+	buckets *buckets[netip.Prefix]
+	limited atomic.Uint64
+}
+
+// NewLimiter allows each subnet, a single address unless ipv4Prefix or ipv6Prefix are set, rate queries per
+// second with bursts of up to burst queries.
+func NewLimiter(rate float64, burst, ipv4Prefix, ipv6Prefix int) *Limiter {
+	return &Limiter{
+		subnets: newSubnets(ipv4Prefix, ipv6Prefix),
+		buckets: newBuckets[netip.Prefix](rate, burst),
+	}
+}
+
+// Allow reports whether a query from ip is allowed. When it is not, first reports whether this is the
+// first query limited since the subnet was last idle long enough to refill its bucket.
+func (l *Limiter) Allow(ip netip.Addr) (allowed bool, first bool) {
+	ok, limited := l.buckets.take(l.subnets.of(ip), time.Now())
+	if ok {
+		return true, false
+	}
+	l.limited.Add(1)
+	return false, limited == 1
+}
+
+// Subnet returns the subnet ip is limited as.
+func (l *Limiter) Subnet(ip netip.Addr) netip.Prefix {
+	return l.subnets.of(ip)
+}
+
+// Limited returns the number of queries limited so far.
+func (l *Limiter) Limited() uint64 {
+	return l.limited.Load()
+}
+
+// Action is what to do with a response, see ResponseLimiter.
+type Action int
+
+const (
+	Send Action = iota
+	Slip
+	Drop
+)
+
+type responseKey struct {
+	subnet netip.Prefix
+	token  string
+}
+
+// ResponseLimiter limits identical responses sent to a subnet, as done by response rate limiting (RRL) to
+// keep the server from being used to reflect traffic at a spoofed address.
+type ResponseLimiter struct {
+	subnets subnets
+	buckets *buckets[responseKey]
+	slip    uint64
+	limited atomic.Uint64
+}
+
+// NewResponseLimiter allows rate identical responses per second to each subnet. Every slip-th response over
+// the limit is slipped, sent truncated so that legitimate clients retry over TCP, and the others are dropped.
+// A slip of 0 drops every response over the limit.
+func NewResponseLimiter(rate float64, slip, ipv4Prefix, ipv6Prefix int) *ResponseLimiter {
+	return &ResponseLimiter{
+		subnets: newSubnets(ipv4Prefix, ipv6Prefix),
+		buckets: newBuckets[responseKey](rate, int(rate)),
+		slip:    uint64(max(0, slip)),
+	}
+}
+
+// Check returns what to do with the response identified by token, e.g. its name and type, sent to ip.
+func (r *ResponseLimiter) Check(ip netip.Addr, token string) Action {
+	ok, limited := r.buckets.take(responseKey{subnet: r.subnets.of(ip), token: token}, time.Now())
+	if ok {
+		return Send
+	}
+
+	r.limited.Add(1)
+	if r.slip > 0 && limited%r.slip == 0 {
+		return Slip
+	}
+	return Drop
+}
+
+// Limited returns the number of responses slipped or dropped so far.
+func (r *ResponseLimiter) Limited() uint64 {
+	return r.limited.Load()
+}
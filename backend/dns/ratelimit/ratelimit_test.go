@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestBucketsRefill(t *testing.T) {
+	b := newBuckets[string](2, 3)
+	now := time.Now()
+
+	for i := range 3 {
+		if ok, _ := b.take("client", now); !ok {
+			t.Fatalf("expected query %d within the burst to be allowed", i+1)
+		}
+	}
+	if ok, limited := b.take("client", now); ok || limited != 1 {
+		t.Fatalf("take() = %v, %d, want false, 1", ok, limited)
+	}
+	if ok, _ := b.take("other", now); !ok {
+		t.Fatal("expected another client to have its own bucket")
+	}
+
+	if ok, _ := b.take("client", now.Add(500*time.Millisecond)); !ok {
+		t.Fatal("expected a token to be refilled after half a second")
+	}
+	if ok, _ := b.take("client", now.Add(500*time.Millisecond)); ok {
+		t.Fatal("expected only a single token to be refilled")
+	}
+}
+
+func TestLimiterGroupsSubnets(t *testing.T) {
+	l := NewLimiter(1, 1, 24, 0)
+
+	if allowed, _ := l.Allow(netip.MustParseAddr("192.0.2.1")); !allowed {
+		t.Fatal("expected the first query to be allowed")
+	}
+	allowed, first := l.Allow(netip.MustParseAddr("192.0.2.200"))
+	if allowed || !first {
+		t.Fatalf("Allow() = %v, %v, want false, true", allowed, first)
+	}
+	if allowed, first := l.Allow(netip.MustParseAddr("::ffff:192.0.2.7")); allowed || first {
+		t.Fatalf("Allow() = %v, %v, want false, false", allowed, first)
+	}
+	if allowed, _ := l.Allow(netip.MustParseAddr("198.51.100.1")); !allowed {
+		t.Fatal("expected another subnet to be allowed")
+	}
+
+	if got, want := l.Subnet(netip.MustParseAddr("2001:db8:1:2::1")), netip.MustParsePrefix("2001:db8:1::/56"); got != want {
+		t.Errorf("Subnet() = %s, want %s", got, want)
+	}
+	if got := l.Limited(); got != 2 {
+		t.Errorf("Limited() = %d, want 2", got)
+	}
+}
+
+func TestResponseLimiterSlips(t *testing.T) {
+	r := NewResponseLimiter(1, 2, 0, 0)
+	ip := netip.MustParseAddr("192.0.2.1")
+
+	want := []Action{Send, Drop, Slip, Drop, Slip}
+	for i, action := range want {
+		if got := r.Check(ip, "example.com./A"); got != action {
+			t.Errorf("response %d: Check() = %d, want %d", i+1, got, action)
+		}
+	}
+	if got := r.Check(ip, "example.org./A"); got != Send {
+		t.Errorf("expected a different response to be sent, got %d", got)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/settings"
+	"io"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"codeberg.org/miekg/dns"
+)
+
+const (
+	accessActionRefuse = "refuse"
+	accessActionDrop   = "drop"
+
+	// Bounds the number of addresses denied queries are counted for, as UDP source addresses can be spoofed
+	maxDeniedClients = 10000
+)
+
+// accessList matches clients by address, range or client ID.
+type accessList struct {
+	prefixes []netip.Prefix
+	ids      map[string]bool
+}
+
+func parseAccessList(entries []string) (accessList, error) {
+	list := accessList{ids: map[string]bool{}}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case strings.Contains(entry, "/"):
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return accessList{}, fmt.Errorf("invalid range '%s': %w", entry, err)
+			}
+			list.prefixes = append(list.prefixes, prefix.Masked())
+		case model.ValidClientID(strings.ToLower(entry)):
+			list.ids[strings.ToLower(entry)] = true
+		default:
+			ip, err := netip.ParseAddr(entry)
+			if err != nil {
+				return accessList{}, fmt.Errorf("invalid address or client ID '%s'", entry)
+			}
+			list.prefixes = append(list.prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+		}
+	}
+	return list, nil
+}
+
+func (l accessList) empty() bool {
+	return len(l.prefixes) == 0 && len(l.ids) == 0
+}
+
+func (l accessList) matches(ip netip.Addr, clientID string) bool {
+	if clientID != "" && l.ids[clientID] {
+		return true
+	}
+	ip = ip.Unmap()
+	for _, prefix := range l.prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessControl decides which clients are answered, see settings.AccessConfig.
+type accessControl struct {
+	allow accessList
+	deny  accessList
+	drop  bool
+}
+
+func newAccessControl(config settings.AccessConfig) (*accessControl, error) {
+	allow, err := parseAccessList(config.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+	deny, err := parseAccessList(config.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+
+	switch config.Action {
+	case "", accessActionRefuse, accessActionDrop:
+	default:
+		return nil, fmt.Errorf("unknown access action '%s', expected '%s' or '%s'", config.Action, accessActionRefuse, accessActionDrop)
+	}
+
+	return &accessControl{allow: allow, deny: deny, drop: config.Action == accessActionDrop}, nil
+}
+
+func (a *accessControl) allowed(ip netip.Addr, clientID string) bool {
+	if a.deny.matches(ip, clientID) {
+		return false
+	}
+	return a.allow.empty() || a.allow.matches(ip, clientID)
+}
+
+// deniedQueries counts queries refused or dropped by the access control lists.
+type deniedQueries struct {
+	total   atomic.Uint64
+	mu      sync.Mutex
+	clients map[netip.Addr]uint64
+}
+
+// add counts a denied query from ip and reports whether it is the first one from that address.
+func (d *deniedQueries) add(ip netip.Addr) bool {
+	d.total.Add(1)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.clients == nil {
+		d.clients = map[netip.Addr]uint64{}
+	}
+
+	count, seen := d.clients[ip]
+	if !seen && len(d.clients) >= maxDeniedClients {
+		return false
+	}
+	d.clients[ip] = count + 1
+	return !seen
+}
+
+// DeniedQueries returns the number of denied queries in total and per client address.
+func (s *DNSServer) DeniedQueries() (uint64, map[string]uint64) {
+	s.denied.mu.Lock()
+	defer s.denied.mu.Unlock()
+
+	clients := make(map[string]uint64, len(s.denied.clients))
+	for ip, count := range s.denied.clients {
+		clients[ip.String()] = count
+	}
+	return s.denied.total.Load(), clients
+}
+
+// ReloadAccessControl applies the access control lists of the current settings.
+func (s *DNSServer) ReloadAccessControl() error {
+	access, err := newAccessControl(s.Config.DNS.Access)
+	if err != nil {
+		return err
+	}
+	s.access.Store(access)
+	return nil
+}
+
+// ValidateAccessControl reports whether config holds valid access control lists.
+func ValidateAccessControl(config settings.AccessConfig) error {
+	_, err := newAccessControl(config)
+	return err
+}
+
+// checkAccess reports whether the client may query, answering REFUSED or dropping the query when it may not.
+func (s *DNSServer) checkAccess(w dns.ResponseWriter, r *dns.Msg, tap clientTap, clientIP netip.Addr, clientID string) bool {
+	access := s.access.Load()
+	if access == nil || access.allowed(clientIP, clientID) {
+		return true
+	}
+
+	if s.denied.add(clientIP) {
+		log.Warning("Denied query from %s by access control lists", clientIP)
+	} else {
+		log.Debug("Denied query from %s by access control lists", clientIP)
+	}
+
+	if !access.drop {
+		refuse(w, r, tap, clientIP)
+	}
+	return false
+}
+
+// refuse answers the query with REFUSED.
+func refuse(w dns.ResponseWriter, r *dns.Msg, tap clientTap, clientIP netip.Addr) {
+	r.Response = true
+	r.Rcode = dns.RcodeRefused
+	r.Answer, r.Ns, r.Extra = nil, nil, nil
+	if err := r.Pack(); err != nil {
+		log.Warning("Failed to pack REFUSED response for %s: %v", clientIP, err)
+		return
+	}
+	tap.response(r.Data)
+	if _, err := io.Copy(w, r); err != nil {
+		log.Debug("Failed to write REFUSED response to %s: %v", clientIP, err)
+	}
+}
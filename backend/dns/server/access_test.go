@@ -0,0 +1,60 @@
+package server
+
+import (
+	"goaway/backend/settings"
+	"net/netip"
+	"testing"
+)
+
+func TestAccessControl(t *testing.T) {
+	access, err := newAccessControl(settings.AccessConfig{
+		Allow: []string{"192.168.1.0/24", "2001:db8::/32", "my-phone"},
+		Deny:  []string{"192.168.1.66"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip       string
+		clientID string
+		want     bool
+	}{
+		{"192.168.1.10", "", true},
+		{"::ffff:192.168.1.10", "", true},
+		{"2001:db8::1", "", true},
+		{"192.168.1.66", "", false},
+		{"192.168.1.66", "my-phone", false},
+		{"203.0.113.5", "", false},
+		{"203.0.113.5", "my-phone", true},
+		{"203.0.113.5", "other", false},
+	}
+
+	for _, tt := range tests {
+		if got := access.allowed(netip.MustParseAddr(tt.ip), tt.clientID); got != tt.want {
+			t.Errorf("allowed(%s, %q) = %v, want %v", tt.ip, tt.clientID, got, tt.want)
+		}
+	}
+}
+
+func TestAccessControlDefaultsToAllowingEveryone(t *testing.T) {
+	access, err := newAccessControl(settings.AccessConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !access.allowed(netip.MustParseAddr("203.0.113.5"), "") {
+		t.Error("expected empty lists to allow every client")
+	}
+}
+
+func TestAccessControlRejectsInvalidEntries(t *testing.T) {
+	for _, config := range []settings.AccessConfig{
+		{Allow: []string{"10.0.0.0/33"}},
+		{Deny: []string{"not_an_id"}},
+		{Action: "ignore"},
+	} {
+		if _, err := newAccessControl(config); err == nil {
+			t.Errorf("expected %+v to be rejected", config)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/zone"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+)
+
+// handleAuthoritative answers a query for a name within one of the locally loaded zones.
+func (s *DNSServer) handleAuthoritative(request *Request, z *zone.Zone) model.RequestLogEntry {
+	answer := z.Lookup(request.QName(), request.QType())
+
+	request.Msg.Response = true
+	request.Msg.Authoritative = answer.Authoritative
+	request.Msg.RecursionAvailable = true
+	request.Msg.Rcode = answer.Rcode
+	request.Msg.Answer = answer.Answer
+	request.Msg.Ns = answer.Ns
+
+	resolved := make([]model.ResolvedIP, 0, len(answer.Answer))
+	for _, rr := range answer.Answer {
+		switch rr := rr.(type) {
+		case *dns.A:
+			resolved = append(resolved, model.ResolvedIP{IP: rr.Addr, RType: "A"})
+		case *dns.AAAA:
+			resolved = append(resolved, model.ResolvedIP{IP: rr.Addr, RType: "AAAA"})
+		}
+	}
+
+	request.Respond(s.NotificationService)
+	return model.RequestLogEntry{
+		Domain:            request.QName(),
+		Status:            dnsutil.CodeToString(answer.Rcode),
+		QueryType:         request.QTypeStr(),
+		IP:                resolved,
+		ResponseSizeBytes: request.Msg.Len(),
+		Timestamp:         request.Sent,
+		ResponseTime:      time.Since(request.Sent),
+		ClientInfo:        request.Client,
+		Protocol:          request.Protocol,
+	}
+}
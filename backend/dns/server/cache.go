@@ -1,6 +1,7 @@
 package server
 
 import (
+	"goaway/backend/metrics"
 	"time"
 
 	"codeberg.org/miekg/dns"
@@ -32,7 +33,9 @@ func (s *DNSServer) getCachedRecord(cached interface{}) ([]dns.RR, bool) {
 
 	if cachedRecord.Key != "" {
 		log.Debug("Cached entry has expired, removing %s from cache", cachedRecord.Key)
-		s.DomainCache.Delete(cachedRecord.Key)
+		if _, loaded := s.DomainCache.LoadAndDelete(cachedRecord.Key); loaded {
+			metrics.ObserveCacheEviction(metrics.CacheExpired)
+		}
 	}
 
 	return nil, false
@@ -51,6 +54,7 @@ func (s *DNSServer) RemoveCachedDomain(domain string) {
 
 		log.Debug("Removing cached record for domain %s", domain)
 		s.DomainCache.Delete(key)
+		metrics.ObserveCacheEviction(metrics.CacheRemoved)
 		return true
 	})
 }
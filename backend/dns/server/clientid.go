@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"net/netip"
+	"strings"
+
+	"codeberg.org/miekg/dns"
+)
+
+type clientIDContextKey struct{}
+
+// clientIDFromPath returns the client ID of a DoH request made to "{prefix}/{clientID}", e.g. "/dns-query/{clientID}".
+func clientIDFromPath(path, prefix string) (string, error) {
+	id := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if id == "" {
+		return "", nil
+	}
+
+	id = strings.ToLower(id)
+	if !model.ValidClientID(id) {
+		return "", fmt.Errorf("invalid client ID '%s'", id)
+	}
+	return id, nil
+}
+
+// clientIDFromServerName returns the client ID of a DoT connection made to "{clientID}.{domain}".
+func clientIDFromServerName(serverName, domain string) string {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	serverName = strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if domain == "" {
+		return ""
+	}
+
+	id, found := strings.CutSuffix(serverName, "."+domain)
+	if !found || !model.ValidClientID(id) {
+		return ""
+	}
+	return id
+}
+
+// clientID returns the client ID presented by a DoH or DoT client, if any.
+func (s *DNSServer) clientID(ctx context.Context, w dns.ResponseWriter) string {
+	if id, ok := ctx.Value(clientIDContextKey{}).(string); ok {
+		return id
+	}
+
+	if conn, ok := w.Conn().(*tls.Conn); ok {
+		return clientIDFromServerName(conn.ConnectionState().ServerName, s.Config.DNS.TLS.ServerName)
+	}
+	return ""
+}
+
+// getClientByID returns the client using the given client ID. Its address is the one the current
+// query was sent from, as clients behind NAT share their address with others. A client ID without
+// logged queries gets the name and bypass configured for it, if any.
+func (s *DNSServer) getClientByID(id string, clientIP netip.Addr) *model.Client {
+	client := &model.Client{ID: id, Name: id}
+	if loaded, ok := s.clientIDCache.Load(id); ok {
+		if cached, ok := loaded.(*model.Client); ok {
+			client = cached
+		}
+	} else {
+		if stored, err := s.RequestService.FetchClient(id); err == nil {
+			client = stored
+		}
+		s.clientIDCache.Store(id, client)
+	}
+
+	if client.IP == clientIP {
+		return client
+	}
+
+	copied := *client
+	copied.IP = clientIP
+	return &copied
+}
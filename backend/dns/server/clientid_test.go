@@ -0,0 +1,52 @@
+package server
+
+import "testing"
+
+func TestClientIDFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{path: "/dns-query"},
+		{path: "/dns-query/"},
+		{path: "/dns-query/my-phone", want: "my-phone"},
+		{path: "/dns-query/Laptop-2", want: "laptop-2"},
+		{path: "/dns-query/bad_id", wantErr: true},
+		{path: "/dns-query/-phone", wantErr: true},
+		{path: "/resolve/my-phone", prefix: jsonPath, want: "my-phone"},
+	}
+
+	for _, tt := range tests {
+		prefix := tt.prefix
+		if prefix == "" {
+			prefix = "/dns-query"
+		}
+		got, err := clientIDFromPath(tt.path, prefix)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("clientIDFromPath(%q) = %q, %v", tt.path, got, err)
+		}
+	}
+}
+
+func TestClientIDFromServerName(t *testing.T) {
+	tests := []struct {
+		serverName string
+		domain     string
+		want       string
+	}{
+		{"my-phone.dns.example.com", "dns.example.com", "my-phone"},
+		{"My-Phone.dns.example.com.", "dns.example.com.", "my-phone"},
+		{"dns.example.com", "dns.example.com", ""},
+		{"a.b.dns.example.com", "dns.example.com", ""},
+		{"my-phone.other.example.com", "dns.example.com", ""},
+		{"my-phone.dns.example.com", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := clientIDFromServerName(tt.serverName, tt.domain); got != tt.want {
+			t.Errorf("clientIDFromServerName(%q, %q) = %q, want %q", tt.serverName, tt.domain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+)
+
+const (
+	HostnameLookupReverse = "reverse"
+	HostnameLookupMDNS    = "mdns"
+	HostnameLookupNetBIOS = "netbios"
+	HostnameLookupSSH     = "ssh"
+
+	discoveryTimeout = 2 * time.Second
+)
+
+// DefaultHostnameLookup is used when no lookup chain is configured.
+var DefaultHostnameLookup = []string{HostnameLookupReverse, HostnameLookupMDNS, HostnameLookupSSH}
+
+func (s *DNSServer) hostnameLookups() []func(netip.Addr) string {
+	chain := s.Config.Clients.HostnameLookup
+	if chain == nil {
+		chain = DefaultHostnameLookup
+	}
+
+	lookups := make([]func(netip.Addr) string, 0, len(chain))
+	for _, method := range chain {
+		switch method {
+		case HostnameLookupReverse:
+			lookups = append(lookups, s.reverseDNSLookup)
+		case HostnameLookupMDNS:
+			lookups = append(lookups, mdnsLookup)
+		case HostnameLookupNetBIOS:
+			lookups = append(lookups, netbiosLookup)
+		case HostnameLookupSSH:
+			lookups = append(lookups, s.sshBannerLookup)
+		default:
+			log.Warning("Unknown hostname lookup method '%s'", method)
+		}
+	}
+	return lookups
+}
+
+// mdnsLookup sends a reverse PTR query straight to the client's mDNS responder. As the query is not sent
+// from port 5353 the responder answers with a unicast "legacy" response (RFC 6762, section 6.7).
+func mdnsLookup(clientIP netip.Addr) string {
+	query := dns.NewMsg(dnsutil.ReverseAddr(clientIP), dns.TypePTR)
+	query.ID = dns.ID()
+	if err := query.Pack(); err != nil {
+		return unknownHostname
+	}
+
+	response, err := exchangeUDP(netip.AddrPortFrom(clientIP, 5353), query.Data)
+	if err != nil {
+		return unknownHostname
+	}
+
+	msg := &dns.Msg{Data: response}
+	if err := msg.Unpack(); err != nil || msg.ID != query.ID {
+		return unknownHostname
+	}
+
+	for _, answer := range msg.Answer {
+		if ptr, ok := answer.(*dns.PTR); ok {
+			hostname := strings.TrimSuffix(strings.TrimSuffix(ptr.Ptr, "."), ".local")
+			if hostname != "" {
+				log.Debug("Found hostname via mDNS: %s -> %s", clientIP, hostname)
+				return hostname
+			}
+		}
+	}
+
+	return unknownHostname
+}
+
+// netbiosLookup sends a NetBIOS node status (NBSTAT) request and returns the workstation name, which
+// is how Windows machines without mDNS announce themselves.
+func netbiosLookup(clientIP netip.Addr) string {
+	if !clientIP.Is4() {
+		return unknownHostname
+	}
+
+	response, err := exchangeUDP(netip.AddrPortFrom(clientIP, 137), nbstatRequest())
+	if err != nil {
+		return unknownHostname
+	}
+
+	hostname, err := parseNBSTATResponse(response)
+	if err != nil {
+		log.Debug("Invalid NetBIOS response from %s: %v", clientIP, err)
+		return unknownHostname
+	}
+
+	log.Debug("Found hostname via NetBIOS: %s -> %s", clientIP, hostname)
+	return hostname
+}
+
+func nbstatRequest() []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, [6]uint16{uint16(dns.ID()), 0, 1, 0, 0, 0})
+
+	// The wildcard name "*" padded with NULs, in NetBIOS first-level encoding
+	buf.WriteByte(32)
+	name := [16]byte{'*'}
+	for _, b := range name {
+		buf.WriteByte('A' + b>>4)
+		buf.WriteByte('A' + b&0x0f)
+	}
+	buf.WriteByte(0)
+
+	_ = binary.Write(&buf, binary.BigEndian, [2]uint16{0x0021, 0x0001}) // NBSTAT, IN
+	return buf.Bytes()
+}
+
+// parseNBSTATResponse returns the first unique workstation name (suffix 0x00) in a node status response.
+func parseNBSTATResponse(data []byte) (string, error) {
+	const headerLen = 12
+	if len(data) < headerLen || binary.BigEndian.Uint16(data[6:8]) == 0 {
+		return "", fmt.Errorf("no answer")
+	}
+
+	offset := headerLen
+	for offset < len(data) && data[offset] != 0 && data[offset]&0xc0 != 0xc0 {
+		offset += int(data[offset]) + 1
+	}
+	if offset < len(data) && data[offset]&0xc0 == 0xc0 {
+		offset += 2 // Compression pointer
+	} else {
+		offset++ // Root label
+	}
+	// Skip type, class, TTL and rdlength
+	offset += 2 + 2 + 4 + 2
+	if offset >= len(data) {
+		return "", fmt.Errorf("truncated response")
+	}
+
+	count := int(data[offset])
+	offset++
+	for i := 0; i < count; i++ {
+		if offset+18 > len(data) {
+			return "", fmt.Errorf("truncated name table")
+		}
+		entry := data[offset : offset+18]
+		offset += 18
+
+		suffix := entry[15]
+		group := binary.BigEndian.Uint16(entry[16:18])&0x8000 != 0
+		if suffix == 0x00 && !group {
+			if name := strings.TrimSpace(string(entry[:15])); name != "" {
+				return strings.ToLower(name), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no workstation name")
+}
+
+func exchangeUDP(addr netip.AddrPort, request []byte) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(addr))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := conn.SetDeadline(time.Now().Add(discoveryTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
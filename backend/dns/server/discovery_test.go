@@ -0,0 +1,41 @@
+package server
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseNBSTATResponse(t *testing.T) {
+	request := nbstatRequest()
+
+	response := append([]byte{}, request[:12]...)
+	binary.BigEndian.PutUint16(response[2:4], 0x8400)
+	binary.BigEndian.PutUint16(response[4:6], 0)
+	binary.BigEndian.PutUint16(response[6:8], 1)
+	response = append(response, request[12:12+34]...)
+	response = append(response, 0x00, 0x21, 0x00, 0x01, 0, 0, 0, 0, 0x00, 0x00)
+
+	entry := func(name string, suffix byte, flags uint16) []byte {
+		e := make([]byte, 18)
+		copy(e, []byte(name + "               ")[:15])
+		e[15] = suffix
+		binary.BigEndian.PutUint16(e[16:], flags)
+		return e
+	}
+	response = append(response, 3)
+	response = append(response, entry("WORKGROUP", 0x00, 0x8400)...)
+	response = append(response, entry("DESKTOP-1A2B", 0x20, 0x0400)...)
+	response = append(response, entry("DESKTOP-1A2B", 0x00, 0x0400)...)
+
+	hostname, err := parseNBSTATResponse(response)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if hostname != "desktop-1a2b" {
+		t.Errorf("hostname = %s, want desktop-1a2b", hostname)
+	}
+
+	if _, err := parseNBSTATResponse(response[:20]); err == nil {
+		t.Error("expected truncated response to fail")
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/dnstap"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+
+	"codeberg.org/miekg/dns"
+)
+
+// ReloadDnstap starts sending dnstap messages as configured, replacing the current output when the settings
+// changed.
+func (s *DNSServer) ReloadDnstap() error {
+	config := s.Config.DNS.Dnstap
+	if err := dnstap.Validate(config); err != nil {
+		return err
+	}
+
+	s.dnstapLock.Lock()
+	defer s.dnstapLock.Unlock()
+
+	current := s.dnstap.Load()
+	if current != nil && config == s.dnstapConfig {
+		return nil
+	}
+	if current != nil {
+		s.dnstap.Store(nil)
+		current.Close()
+	}
+
+	s.dnstapConfig = config
+	if !config.Enabled {
+		return nil
+	}
+
+	output, err := dnstap.NewOutput(config, "goaway")
+	if err != nil {
+		return err
+	}
+	s.dnstap.Store(output)
+	return nil
+}
+
+// CloseDnstap ends the dnstap stream, sending the messages still queued.
+func (s *DNSServer) CloseDnstap() {
+	s.dnstapLock.Lock()
+	defer s.dnstapLock.Unlock()
+
+	if output := s.dnstap.Swap(nil); output != nil {
+		output.Close()
+	}
+}
+
+// clientTap sends the CLIENT_QUERY and CLIENT_RESPONSE messages of a query received on w. Its output is
+// nil when dnstap is disabled.
+type clientTap struct {
+	output   *dnstap.Output
+	w        dns.ResponseWriter
+	protocol model.Protocol
+	sent     time.Time
+}
+
+// query sends the CLIENT_QUERY message. The message is turned into the response while the query is
+// processed, so the query is copied first.
+func (t clientTap) query(data []byte) {
+	if t.output == nil {
+		return
+	}
+	t.output.Send(dnstap.Message{
+		Type:            dnstap.ClientQuery,
+		Protocol:        socketProtocol(t.protocol),
+		QueryAddress:    addrPort(t.w.RemoteAddr()),
+		ResponseAddress: addrPort(t.w.LocalAddr()),
+		QueryTime:       t.sent,
+		QueryMessage:    slices.Clone(data),
+	})
+}
+
+// response sends the CLIENT_RESPONSE message of a packed response.
+func (t clientTap) response(data []byte) {
+	if t.output == nil {
+		return
+	}
+	t.output.Send(dnstap.Message{
+		Type:            dnstap.ClientResponse,
+		Protocol:        socketProtocol(t.protocol),
+		QueryAddress:    addrPort(t.w.RemoteAddr()),
+		ResponseAddress: addrPort(t.w.LocalAddr()),
+		QueryTime:       t.sent,
+		ResponseTime:    time.Now(),
+		ResponseMessage: slices.Clone(data),
+	})
+}
+
+// tapForwarder sends a FORWARDER_QUERY or FORWARDER_RESPONSE message for a query sent to upstream.
+func tapForwarder(tap *dnstap.Output, messageType dnstap.MessageType, network, upstream string, sent time.Time, data []byte) {
+	message := dnstap.Message{
+		Type:      messageType,
+		Protocol:  dnstap.UDP,
+		QueryTime: sent,
+	}
+	if network == "tcp" {
+		message.Protocol = dnstap.TCP
+	}
+	if addr, err := netip.ParseAddrPort(upstream); err == nil {
+		message.ResponseAddress = addr
+	}
+
+	if messageType == dnstap.ForwarderQuery {
+		message.QueryMessage = data
+	} else {
+		message.ResponseTime = time.Now()
+		message.ResponseMessage = data
+	}
+	tap.Send(message)
+}
+
+func socketProtocol(protocol model.Protocol) dnstap.SocketProtocol {
+	switch protocol {
+	case model.TCP:
+		return dnstap.TCP
+	case model.DoT:
+		return dnstap.DoT
+	case model.DoH:
+		return dnstap.DoH
+	case model.DoQ:
+		return dnstap.DoQ
+	default:
+		return dnstap.UDP
+	}
+}
+
+func addrPort(addr net.Addr) netip.AddrPort {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.AddrPort()
+	case *net.TCPAddr:
+		return addr.AddrPort()
+	default:
+		return netip.AddrPort{}
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"goaway/backend/dnstap"
+	"goaway/backend/settings"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type recordingWriter struct {
+	written bytes.Buffer
+}
+
+func (w *recordingWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 53}
+}
+func (w *recordingWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(192, 168, 1, 66), Port: 53000}
+}
+func (w *recordingWriter) Conn() net.Conn              { return nil }
+func (w *recordingWriter) Write(b []byte) (int, error) { return w.written.Write(b) }
+func (w *recordingWriter) Close() error                { return nil }
+func (w *recordingWriter) Session() *dns.Session       { return nil }
+func (w *recordingWriter) Hijack()                     {}
+
+// tappedTypes returns the dnstap message types written to the Frame Streams file at path.
+func tappedTypes(t *testing.T, path string) []dnstap.MessageType {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []dnstap.MessageType
+	r := bytes.NewReader(data)
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return types
+		}
+		length := binary.BigEndian.Uint32(size[:])
+		if length == 0 {
+			// Control frame
+			if _, err := io.ReadFull(r, size[:]); err != nil {
+				return types
+			}
+			_, _ = r.Seek(int64(binary.BigEndian.Uint32(size[:])), io.SeekCurrent)
+			continue
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, dnstap.MessageType(protoField(t, protoField(t, frame, 14), 1)[0]))
+	}
+}
+
+// protoField returns the value of field number in a protobuf message.
+func protoField(t *testing.T, b []byte, number protowire.Number) []byte {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if num == number {
+			if typ == protowire.BytesType {
+				value, _ := protowire.ConsumeBytes(b)
+				return value
+			}
+			return b[:m]
+		}
+		b = b[m:]
+	}
+	t.Fatalf("field %d not found", number)
+	return nil
+}
+
+func TestDnstapRefusedAndMalformedQueries(t *testing.T) {
+	tests := []struct {
+		name   string
+		access settings.AccessConfig
+		query  *dns.Msg
+		rcode  uint16
+		want   []dnstap.MessageType
+	}{
+		{"refused", settings.AccessConfig{Deny: []string{"192.168.1.66"}}, dns.NewMsg("example.com.", dns.TypeA), dns.RcodeRefused, []dnstap.MessageType{dnstap.ClientQuery, dnstap.ClientResponse}},
+		{"dropped", settings.AccessConfig{Deny: []string{"192.168.1.66"}, Action: "drop"}, dns.NewMsg("example.com.", dns.TypeA), 0, []dnstap.MessageType{dnstap.ClientQuery}},
+		{"no question", settings.AccessConfig{}, &dns.Msg{}, dns.RcodeFormatError, []dnstap.MessageType{dnstap.ClientQuery, dnstap.ClientResponse}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dnstap.fstrm")
+			output, err := dnstap.NewOutput(settings.DnstapConfig{Enabled: true, Network: dnstap.NetworkFile, Address: path}, "goaway")
+			if err != nil {
+				t.Fatal(err)
+			}
+			access, err := newAccessControl(tt.access)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			server := &DNSServer{Config: &settings.Config{}}
+			server.dnstap.Store(output)
+			server.access.Store(access)
+
+			if err := tt.query.Pack(); err != nil {
+				t.Fatal(err)
+			}
+			w := &recordingWriter{}
+			server.ServeDNS(context.Background(), w, tt.query)
+			output.Close()
+
+			if tt.rcode != 0 {
+				// Writes without a UDP connection are prefixed with their length
+				response := &dns.Msg{Data: w.written.Bytes()[2:]}
+				if err := response.Unpack(); err != nil {
+					t.Fatalf("invalid response: %v", err)
+				}
+				if !response.Response || response.Rcode != tt.rcode {
+					t.Errorf("response = %s, %v, want %s", dns.RcodeToString[response.Rcode], response.Response, dns.RcodeToString[tt.rcode])
+				}
+			}
+
+			files, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "dnstap-*.fstrm"))
+			if len(files) != 1 {
+				t.Fatalf("got dnstap files %v, want 1", files)
+			}
+			got := tappedTypes(t, files[0])
+			if len(got) != len(tt.want) {
+				t.Fatalf("tapped %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tapped %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"goaway/backend/certificate"
 	model "goaway/backend/dns/server/models"
 	"net"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"codeberg.org/miekg/dns/dnshttp"
+	"github.com/quic-go/quic-go/http3"
 )
 
 const (
@@ -18,21 +23,29 @@ const (
 	megabyte        = 1 << 20
 )
 
-func (s *DNSServer) InitDoH(cert tls.Certificate) (*http.Server, error) {
+var validDoHPath = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+func (s *DNSServer) InitDoH(certificates *certificate.Service) (*http.Server, error) {
+	trusted, err := parseTrustedProxies(s.Config.DNS.DoH.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.DoHPath()
+	if !validDoHPath.MatchString(path) || path == jsonPath || path == "/health" {
+		return nil, fmt.Errorf("invalid DoH path '%s'", path)
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc(dnshttp.Path, s.handleDoHRequest)
+	mux.HandleFunc(path, s.handleDoHRequest)
+	mux.HandleFunc(path+"/{clientID}", s.handleDoHRequest)
+	mux.HandleFunc(jsonPath, s.handleJSONRequest)
+	mux.HandleFunc(jsonPath+"/{clientID}", s.handleJSONRequest)
 	mux.HandleFunc("/health", s.handleHealthCheck)
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.Config.DNS.Address, s.Config.DNS.Ports.DoH),
-		Handler: mux,
-		TLSConfig: &tls.Config{
-			Certificates:             []tls.Certificate{cert},
-			MinVersion:               tls.VersionTLS12,
-			MaxVersion:               tls.VersionTLS13,
-			PreferServerCipherSuites: true,
-			NextProtos:               dnshttp.NextProtos,
-		},
+		Addr:              fmt.Sprintf("%s:%d", s.Config.DNS.Address, s.Config.DNS.Ports.DoH),
+		Handler:           trustForwardedHeaders(mux, trusted),
 		ReadTimeout:       doHReadTimeout,
 		WriteTimeout:      doHWriteTimeout,
 		ReadHeaderTimeout: 5 * time.Second,
@@ -40,9 +53,52 @@ func (s *DNSServer) InitDoH(cert tls.Certificate) (*http.Server, error) {
 		MaxHeaderBytes:    1 * megabyte,
 	}
 
+	// TLS is terminated by the reverse proxy in front of a plain HTTP server
+	if !s.Config.DNS.DoH.PlainHTTP {
+		server.TLSConfig = &tls.Config{
+			GetCertificate:           certificates.GetCertificate,
+			MinVersion:               tls.VersionTLS12,
+			MaxVersion:               tls.VersionTLS13,
+			PreferServerCipherSuites: true,
+			NextProtos:               append(slices.Clone(dnshttp.NextProtos), certificates.NextProtos()...),
+		}
+	}
+
 	return server, nil
 }
 
+// DoHPath returns the path DoH wire format queries are answered at.
+func (s *DNSServer) DoHPath() string {
+	path := strings.TrimSuffix(s.Config.DNS.DoH.Path, "/")
+	if path == "" {
+		return dnshttp.Path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// InitDoH3 creates an HTTP/3 server answering the same requests as the DoH server on the same port
+// over UDP. Responses sent by the DoH server advertise it to clients with an Alt-Svc header once it is listening.
+func (s *DNSServer) InitDoH3(doh *http.Server) *http3.Server {
+	h3 := &http3.Server{
+		Addr:           doh.Addr,
+		Handler:        doh.Handler,
+		TLSConfig:      http3.ConfigureTLSConfig(doh.TLSConfig),
+		IdleTimeout:    doh.IdleTimeout,
+		MaxHeaderBytes: doh.MaxHeaderBytes,
+	}
+
+	handler := doh.Handler
+	doh.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = h3.SetQUICHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+
+	return h3
+}
+
 func (s *DNSServer) handleHealthCheck(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -61,6 +117,15 @@ func (s *DNSServer) handleDoHRequest(w http.ResponseWriter, r *http.Request) {
 
 	log.Debug("DoH request received: %s %s from %s", r.Method, r.URL.String(), r.RemoteAddr)
 
+	clientID, err := clientIDFromPath(r.URL.Path, s.DoHPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if clientID != "" {
+		ctxVal = context.WithValue(ctxVal, clientIDContextKey{}, clientID)
+	}
+
 	// Parse the HTTP request into a DNS message
 	msg, err := dnshttp.Request(r)
 	if err != nil {
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"goaway/backend/certificate"
+	"goaway/backend/settings"
+	"slices"
+	"testing"
+
+	"codeberg.org/miekg/dns/dnshttp"
+	"golang.org/x/crypto/acme"
+)
+
+func TestDoHOffersACMEChallengeProtocol(t *testing.T) {
+	tests := []struct {
+		name string
+		tls  settings.TLSConfig
+		want bool
+	}{
+		{"acme", settings.TLSConfig{Enabled: true, ACME: settings.ACMEConfig{Enabled: true, Domains: []string{"dns.example.com"}}}, true},
+		{"local certificate", settings.TLSConfig{Enabled: true, ServerName: "dns.example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		certificates, err := certificate.NewService(tt.tls, t.TempDir())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		server := &DNSServer{Config: &settings.Config{}}
+		doh, err := server.InitDoH(certificates)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		protos := doh.TLSConfig.NextProtos
+		for _, proto := range dnshttp.NextProtos {
+			if !slices.Contains(protos, proto) {
+				t.Errorf("%s: NextProtos = %v, missing %s", tt.name, protos, proto)
+			}
+		}
+		if got := slices.Contains(protos, acme.ALPNProto); got != tt.want {
+			t.Errorf("%s: NextProtos = %v, offers %s = %v, want %v", tt.name, protos, acme.ALPNProto, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+)
+
+const (
+	jsonPath     = "/resolve"
+	jsonMimeType = "application/dns-json"
+)
+
+// jsonResponse is the JSON representation of a DNS response used by the Google and Cloudflare DoH JSON APIs.
+type jsonResponse struct {
+	Status    uint16         `json:"Status"`
+	TC        bool           `json:"TC"`
+	RD        bool           `json:"RD"`
+	RA        bool           `json:"RA"`
+	AD        bool           `json:"AD"`
+	CD        bool           `json:"CD"`
+	Question  []jsonQuestion `json:"Question"`
+	Answer    []jsonRecord   `json:"Answer,omitempty"`
+	Authority []jsonRecord   `json:"Authority,omitempty"`
+}
+
+type jsonQuestion struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+}
+
+type jsonRecord struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+	TTL  uint32 `json:"TTL"`
+	Data string `json:"data"`
+}
+
+// handleJSONRequest answers "/resolve?name=example.com&type=AAAA" with the response as application/dns-json,
+// for scripts and browser tooling unable to build wire format messages.
+func (s *DNSServer) handleJSONRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	ctxVal := context.WithValue(ctx, model.DoH, true)
+	defer cancel()
+
+	clientID, err := clientIDFromPath(r.URL.Path, jsonPath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if clientID != "" {
+		ctxVal = context.WithValue(ctxVal, clientIDContextKey{}, clientID)
+	}
+
+	msg, err := parseJSONQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cw := &captureResponseWriter{
+		laddr: r.Context().Value(http.LocalAddrContextKey).(net.Addr),
+		raddr: r.RemoteAddr,
+	}
+	s.ServeDNS(ctxVal, cw, msg)
+
+	if len(cw.data) == 0 {
+		http.Error(w, "no response", http.StatusBadGateway)
+		return
+	}
+
+	response := &dns.Msg{Data: cw.data}
+	if err := response.Unpack(); err != nil {
+		log.Warning("Failed to unpack DNS response for JSON request: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonMimeType)
+	if err := json.NewEncoder(w).Encode(newJSONResponse(response)); err != nil {
+		log.Warning("Failed to write JSON DNS response: %v", err)
+	}
+}
+
+// parseJSONQuery builds the query for the name, type, cd and do parameters of a JSON API request.
+// The type is either a name such as "AAAA" or its number, and defaults to A.
+func parseJSONQuery(r *http.Request) (*dns.Msg, error) {
+	query := r.URL.Query()
+
+	name := query.Get("name")
+	if name == "" || len(name) > 253 {
+		return nil, errors.New("invalid or missing 'name' parameter")
+	}
+
+	qtype := dns.TypeA
+	if t := query.Get("type"); t != "" {
+		if n, err := strconv.ParseUint(t, 10, 16); err == nil {
+			qtype = uint16(n)
+		} else if known, ok := dns.StringToType[strings.ToUpper(t)]; ok {
+			qtype = known
+		} else {
+			return nil, fmt.Errorf("unknown type '%s'", t)
+		}
+	}
+
+	msg := dns.NewMsg(name, qtype)
+	if msg == nil {
+		return nil, fmt.Errorf("unsupported type '%s'", query.Get("type"))
+	}
+	msg.CheckingDisabled = jsonFlag(query.Get("cd"))
+	msg.Security = jsonFlag(query.Get("do"))
+
+	return msg, nil
+}
+
+func jsonFlag(value string) bool {
+	return value == "1" || strings.EqualFold(value, "true")
+}
+
+func newJSONResponse(msg *dns.Msg) jsonResponse {
+	response := jsonResponse{
+		Status:    msg.Rcode,
+		TC:        msg.Truncated,
+		RD:        msg.RecursionDesired,
+		RA:        msg.RecursionAvailable,
+		AD:        msg.AuthenticatedData,
+		CD:        msg.CheckingDisabled,
+		Question:  make([]jsonQuestion, 0, len(msg.Question)),
+		Answer:    jsonRecords(msg.Answer),
+		Authority: jsonRecords(msg.Ns),
+	}
+
+	for _, q := range msg.Question {
+		response.Question = append(response.Question, jsonQuestion{
+			Name: q.Header().Name,
+			Type: dns.RRToType(q),
+		})
+	}
+
+	return response
+}
+
+func jsonRecords(rrs []dns.RR) []jsonRecord {
+	records := make([]jsonRecord, 0, len(rrs))
+	for _, rr := range rrs {
+		record := jsonRecord{
+			Name: rr.Header().Name,
+			Type: dns.RRToType(rr),
+			TTL:  rr.Header().TTL,
+		}
+		if data := rr.Data(); data != nil {
+			record.Data = data.String()
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// captureResponseWriter keeps the response written by ServeDNS so it can be rendered as JSON.
+type captureResponseWriter struct {
+	laddr net.Addr
+	raddr string
+	data  []byte
+}
+
+func (w *captureResponseWriter) LocalAddr() net.Addr   { return w.laddr }
+func (w *captureResponseWriter) Conn() net.Conn        { return nil }
+func (w *captureResponseWriter) Session() *dns.Session { return nil }
+func (w *captureResponseWriter) Hijack()               {}
+func (w *captureResponseWriter) Close() error          { return nil }
+
+func (w *captureResponseWriter) RemoteAddr() net.Addr {
+	addr, err := net.ResolveTCPAddr("tcp", w.raddr)
+	if err != nil {
+		return &net.TCPAddr{}
+	}
+	return addr
+}
+
+// Write keeps the message, skipping the 2-octet length prefix added by dns.Msg.WriteTo.
+func (w *captureResponseWriter) Write(p []byte) (int, error) {
+	if len(p) < 2 {
+		return 0, errors.New("short DNS response")
+	}
+	w.data = append([]byte(nil), p[2:]...)
+	return len(p), nil
+}
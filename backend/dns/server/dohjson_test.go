@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/rdata"
+)
+
+func TestParseJSONQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		qtype   uint16
+		wantErr bool
+	}{
+		{query: "name=example.com", qtype: dns.TypeA},
+		{query: "name=example.com&type=aaaa", qtype: dns.TypeAAAA},
+		{query: "name=example.com&type=15", qtype: dns.TypeMX},
+		{query: "name=example.com&type=bogus", wantErr: true},
+		{query: "type=A", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		msg, err := parseJSONQuery(httptest.NewRequest("GET", jsonPath+"?"+tt.query, nil))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseJSONQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := dns.RRToType(msg.Question[0]); got != tt.qtype {
+			t.Errorf("parseJSONQuery(%q) type = %d, want %d", tt.query, got, tt.qtype)
+		}
+		if name := msg.Question[0].Header().Name; name != "example.com." {
+			t.Errorf("parseJSONQuery(%q) name = %q", tt.query, name)
+		}
+	}
+}
+
+func TestNewJSONResponse(t *testing.T) {
+	msg := dns.NewMsg("example.com", dns.TypeA)
+	msg.Response = true
+	msg.RecursionAvailable = true
+	msg.Answer = []dns.RR{&dns.A{
+		Hdr: dns.Header{Name: "example.com.", Class: dns.ClassINET, TTL: 300},
+		A:   rdata.A{Addr: netip.MustParseAddr("192.0.2.1")},
+	}}
+
+	response := newJSONResponse(msg)
+	if !response.RA || response.Status != dns.RcodeSuccess {
+		t.Errorf("unexpected header %+v", response)
+	}
+	if len(response.Question) != 1 || response.Question[0].Type != dns.TypeA {
+		t.Errorf("unexpected question %+v", response.Question)
+	}
+	want := jsonRecord{Name: "example.com.", Type: dns.TypeA, TTL: 300, Data: "192.0.2.1"}
+	if len(response.Answer) != 1 || response.Answer[0] != want {
+		t.Errorf("answer = %+v, want %+v", response.Answer, want)
+	}
+}
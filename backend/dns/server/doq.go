@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"goaway/backend/certificate"
+	model "goaway/backend/dns/server/models"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	doqIdleTimeout = 30 * time.Second
+	doqReadTimeout = 5 * time.Second
+
+	// Application error codes from RFC 9250, section 4.3
+	doqNoError          quic.ApplicationErrorCode = 0x0
+	doqProtocolError    quic.ApplicationErrorCode = 0x2
+	doqRequestCancelled quic.ApplicationErrorCode = 0x3
+)
+
+// DoQServer serves DNS-over-QUIC (RFC 9250). Every query is sent on its own stream, prefixed with
+// its length, and answered on the same stream.
+type DoQServer struct {
+	Addr      string
+	handler   *DNSServer
+	tlsConfig *tls.Config
+
+	mu       sync.Mutex
+	listener *quic.Listener
+}
+
+func (s *DNSServer) InitDoQ(certificates *certificate.Service) (*DoQServer, error) {
+	return &DoQServer{
+		Addr:    fmt.Sprintf("%s:%d", s.Config.DNS.Address, s.Config.DNS.Ports.DoQ),
+		handler: s,
+		tlsConfig: &tls.Config{
+			GetCertificate: certificates.GetCertificate,
+			MinVersion:     tls.VersionTLS13,
+			NextProtos:     []string{"doq"},
+		},
+	}, nil
+}
+
+func (d *DoQServer) ListenAndServe() error {
+	listener, err := quic.ListenAddr(d.Addr, d.tlsConfig, &quic.Config{
+		MaxIdleTimeout:  doqIdleTimeout,
+		KeepAlivePeriod: doqIdleTimeout / 2,
+	})
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.listener = listener
+	d.mu.Unlock()
+
+	log.Info("Started DoQ (dns-over-quic) server on %s", d.Addr)
+	for {
+		conn, err := listener.Accept(context.Background())
+		if err != nil {
+			if errors.Is(err, quic.ErrServerClosed) {
+				return nil
+			}
+			return err
+		}
+		go d.handleConnection(conn)
+	}
+}
+
+func (d *DoQServer) Shutdown(_ context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.listener != nil {
+		_ = d.listener.Close()
+	}
+}
+
+func (d *DoQServer) handleConnection(conn *quic.Conn) {
+	ctx := context.WithValue(conn.Context(), model.DoQ, true)
+	if id := clientIDFromServerName(conn.ConnectionState().TLS.ServerName, d.handler.Config.DNS.TLS.ServerName); id != "" {
+		ctx = context.WithValue(ctx, clientIDContextKey{}, id)
+	}
+
+	for {
+		stream, err := conn.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+		go d.handleStream(ctx, conn, stream)
+	}
+}
+
+func (d *DoQServer) handleStream(ctx context.Context, conn *quic.Conn, stream *quic.Stream) {
+	_ = stream.SetReadDeadline(time.Now().Add(doqReadTimeout))
+
+	var length uint16
+	if err := binary.Read(stream, binary.BigEndian, &length); err != nil {
+		stream.CancelRead(quic.StreamErrorCode(doqProtocolError))
+		_ = stream.Close()
+		return
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(stream, data); err != nil {
+		stream.CancelRead(quic.StreamErrorCode(doqProtocolError))
+		_ = stream.Close()
+		return
+	}
+
+	msg := &dns.Msg{Data: data}
+	if err := msg.Unpack(); err != nil || msg.ID != 0 {
+		// The message ID must be 0, anything else is a protocol error for the whole connection
+		log.Debug("Invalid DoQ query from %s: %v", conn.RemoteAddr(), err)
+		_ = conn.CloseWithError(doqProtocolError, "invalid query")
+		return
+	}
+
+	writer := &doqResponseWriter{conn: conn, stream: stream}
+	d.handler.ServeDNS(ctx, writer, msg)
+	if !writer.written {
+		// Dropped queries (access control, rate limiting) get no response; reset the stream so the
+		// client doesn't wait for one until its idle timeout
+		stream.CancelWrite(quic.StreamErrorCode(doqRequestCancelled))
+	}
+}
+
+// doqResponseWriter writes a response to the stream of the query, prefixed with its length.
+type doqResponseWriter struct {
+	conn    *quic.Conn
+	stream  *quic.Stream
+	written bool
+}
+
+func (w *doqResponseWriter) LocalAddr() net.Addr   { return w.conn.LocalAddr() }
+func (w *doqResponseWriter) RemoteAddr() net.Addr  { return w.conn.RemoteAddr() }
+func (w *doqResponseWriter) Conn() net.Conn        { return nil }
+func (w *doqResponseWriter) Session() *dns.Session { return nil }
+func (w *doqResponseWriter) Hijack()               {}
+func (w *doqResponseWriter) Close() error          { return w.stream.Close() }
+
+// Write sends the response, which dns.Msg.WriteTo has already prefixed with its 2-octet length as
+// RFC 9250 requires, then closes the stream.
+func (w *doqResponseWriter) Write(p []byte) (int, error) {
+	w.written = true
+	if _, err := w.stream.Write(p); err != nil {
+		return 0, err
+	}
+	// Closing the stream sends FIN, marking the end of the response
+	return len(p), w.stream.Close()
+}
@@ -4,19 +4,20 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"goaway/backend/certificate"
 	"time"
 
 	"codeberg.org/miekg/dns"
 )
 
-func (s *DNSServer) InitDoT(cert tls.Certificate) (*dns.Server, error) {
+func (s *DNSServer) InitDoT(certificates *certificate.Service) (*dns.Server, error) {
 	notifyReady := func(context.Context) {
 		log.Info("Started DoT (dns-over-tls) server on port %d", s.Config.DNS.Ports.DoT)
 	}
 
 	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		MinVersion:   tls.VersionTLS12,
+		GetCertificate: certificates.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
 	}
 	server := &dns.Server{
 		Addr:              fmt.Sprintf("%s:%d", s.Config.DNS.Address, s.Config.DNS.Ports.DoT),
@@ -0,0 +1,130 @@
+package server
+
+import (
+	arp "goaway/backend/dns"
+	model "goaway/backend/dns/server/models"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	enrichmentWorkers   = 4
+	enrichmentQueueSize = 256
+)
+
+// enrichmentPool runs slow client lookups, such as hostname probes, on a fixed number of workers so
+// that they never hold up a query. Each address is only enriched once at a time.
+type enrichmentPool struct {
+	queue   chan enrichmentJob
+	pending sync.Map
+	enrich  func(enrichmentJob)
+}
+
+type enrichmentJob struct {
+	client *model.Client
+
+	// The client is this machine, which is always named after its hostname
+	loopback bool
+}
+
+func newEnrichmentPool(workers, size int, enrich func(enrichmentJob)) *enrichmentPool {
+	pool := &enrichmentPool{
+		queue:  make(chan enrichmentJob, size),
+		enrich: enrich,
+	}
+	for range workers {
+		go pool.work()
+	}
+	return pool
+}
+
+// enqueue schedules client to be enriched. It reports false when the client could not be queued, either
+// because it already is or because the queue is full.
+func (p *enrichmentPool) enqueue(job enrichmentJob) bool {
+	if _, queued := p.pending.LoadOrStore(job.client.IP, struct{}{}); queued {
+		return false
+	}
+
+	select {
+	case p.queue <- job:
+		return true
+	default:
+		p.pending.Delete(job.client.IP)
+		return false
+	}
+}
+
+func (p *enrichmentPool) work() {
+	for job := range p.queue {
+		p.enrich(job)
+		p.pending.Delete(job.client.IP)
+	}
+}
+
+// provisionalClient returns a client built from what is known locally without probing it, which is
+// used to answer its queries while it is enriched in the background.
+func (s *DNSServer) provisionalClient(clientIP netip.Addr, loopback bool) *model.Client {
+	client := &model.Client{IP: clientIP, LastSeen: time.Now(), Name: unknownHostname}
+	if lease, found := s.LeaseService.Lookup(clientIP); found && lease.Hostname != "" {
+		client.Mac, client.Name = lease.MAC, lease.Hostname
+	}
+	if client.Mac == "" {
+		client.Mac = arp.GetMacAddress(clientIP)
+	}
+
+	// Settings of a known device apply from its first query, not only once it is enriched
+	if client.Mac != unknownHostname {
+		if device, err := s.MACService.FindDevice(client.Mac); err == nil && device != nil {
+			client.Bypass = device.Bypass
+			if device.Name != "" {
+				client.Name = device.Name
+			}
+		}
+	}
+
+	if loopback {
+		if hostname, err := os.Hostname(); err == nil {
+			client.Name = hostname
+		} else {
+			client.Name = "localhost"
+		}
+	}
+	return client
+}
+
+// enrichClient looks up the device, hostname and vendor of a provisional client and replaces it in the
+// client caches.
+func (s *DNSServer) enrichClient(job enrichmentJob) {
+	provisional := job.client
+	client := *provisional
+
+	// Names given to a device follow it to every address it uses
+	device := s.findDevice(client.IP, client.Mac)
+	if device != nil {
+		client.Bypass = device.Bypass
+		client.Vendor = device.Vendor
+	}
+
+	switch {
+	case job.loopback:
+	case device != nil && device.Name != "":
+		client.Name = device.Name
+	case client.Name == unknownHostname:
+		client.Name = s.resolveHostname(client.IP)
+	}
+
+	if client.Vendor == "" {
+		client.Vendor = s.lookupVendor(client.IP.String(), client.Mac)
+	}
+
+	log.Debug("Enriched client %s as '%s'", client.IP, client.Name)
+	if provisional.Name != client.Name {
+		s.clientHostnameCache.Delete(provisional.Name)
+	}
+	s.clientIPCache.Store(client.IP, &client)
+	if client.Name != unknownHostname {
+		s.clientHostnameCache.Store(client.Name, &client)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"goaway/backend/database"
+	"goaway/backend/dhcp"
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/mac"
+	"goaway/backend/settings"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEnrichmentPoolDeduplicatesAddresses(t *testing.T) {
+	var enriched []netip.Addr
+	pool := newEnrichmentPool(0, 1, func(job enrichmentJob) {
+		enriched = append(enriched, job.client.IP)
+	})
+
+	first := netip.MustParseAddr("192.168.1.10")
+	second := netip.MustParseAddr("192.168.1.11")
+
+	if !pool.enqueue(enrichmentJob{client: &model.Client{IP: first}}) {
+		t.Fatal("expected the first client to be queued")
+	}
+	if pool.enqueue(enrichmentJob{client: &model.Client{IP: first}}) {
+		t.Error("expected a queued client not to be queued again")
+	}
+	if pool.enqueue(enrichmentJob{client: &model.Client{IP: second}}) {
+		t.Error("expected a full queue to reject clients")
+	}
+	if _, pending := pool.pending.Load(second); pending {
+		t.Error("expected a rejected client not to be pending")
+	}
+
+	close(pool.queue)
+	pool.work()
+
+	if len(enriched) != 1 || enriched[0] != first {
+		t.Errorf("enriched %v, want [%s]", enriched, first)
+	}
+	if _, pending := pool.pending.Load(first); pending {
+		t.Error("expected an enriched client to no longer be pending")
+	}
+}
+
+func TestProvisionalClientUsesStoredDevice(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	device := database.MacAddress{MAC: "aa:bb:cc:dd:ee:01", IP: "192.168.1.10", Name: "kids-tablet", Bypass: true}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	leases := filepath.Join(t.TempDir(), "dnsmasq.leases")
+	content := "0 aa:bb:cc:dd:ee:01 192.168.1.10 android-1234 *\n0 aa:bb:cc:dd:ee:02 192.168.1.11 laptop *\n"
+	if err := os.WriteFile(leases, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &DNSServer{
+		MACService:   mac.NewService(mac.NewRepository(db)),
+		LeaseService: dhcp.NewService([]settings.LeaseFileConfig{{Path: leases, Format: dhcp.FormatDnsmasq}}),
+	}
+
+	tests := []struct {
+		ip         string
+		wantName   string
+		wantBypass bool
+	}{
+		{"192.168.1.10", "kids-tablet", true},
+		{"192.168.1.11", "laptop", false},
+		{"192.168.1.12", unknownHostname, false},
+	}
+
+	for _, tt := range tests {
+		client := s.provisionalClient(netip.MustParseAddr(tt.ip), false)
+		if client.Name != tt.wantName || client.Bypass != tt.wantBypass {
+			t.Errorf("provisionalClient(%s) = %s, %v, want %s, %v", tt.ip, client.Name, client.Bypass, tt.wantName, tt.wantBypass)
+		}
+	}
+}
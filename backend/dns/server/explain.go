@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"goaway/backend/blacklist"
+	model "goaway/backend/dns/server/models"
+	"net/netip"
+	"strings"
+)
+
+// BlockExplanation lists every rule that takes part in deciding whether a domain is blocked.
+// It mirrors the checks done by shouldBlockQuery so the verdict matches what a client would see.
+type BlockExplanation struct {
+	Domain       string                  `json:"domain"`
+	ClientIP     string                  `json:"clientIP,omitempty"`
+	ClientName   string                  `json:"clientName,omitempty"`
+	Matches      []blacklist.DomainMatch `json:"matches"`
+	Bundle       string                  `json:"bundle,omitempty"`
+	Blacklisted  bool                    `json:"blacklisted"`
+	Whitelisted  bool                    `json:"whitelisted"`
+	ClientBypass bool                    `json:"clientBypass"`
+	Paused       bool                    `json:"paused"`
+	Blocked      bool                    `json:"blocked"`
+}
+
+func (s *DNSServer) ExplainDomain(ctx context.Context, domain, clientIP string) (BlockExplanation, error) {
+	domain = trimDomainDot(strings.TrimSpace(domain))
+
+	matches, err := s.BlacklistService.FindMatches(ctx, domain)
+	if err != nil {
+		return BlockExplanation{}, err
+	}
+
+	s.checkAndUpdatePauseStatus()
+	explanation := BlockExplanation{
+		Domain:      domain,
+		Matches:     matches,
+		Blacklisted: s.BlacklistService.IsBlacklisted(domain),
+		Whitelisted: s.WhitelistService.IsWhitelisted(domain),
+		Paused:      s.Config.DNS.Status.Paused,
+	}
+	explanation.Bundle, _ = s.BundleService.BlockedBy(domain)
+
+	if client := s.lookupKnownClient(clientIP); client != nil {
+		explanation.ClientIP = client.IP.String()
+		explanation.ClientName = client.Name
+		explanation.ClientBypass = client.Bypass
+	} else if clientIP != "" {
+		explanation.ClientIP = clientIP
+	}
+
+	explanation.Blocked = !explanation.ClientBypass &&
+		!explanation.Paused &&
+		(explanation.Blacklisted || explanation.Bundle != "") &&
+		!explanation.Whitelisted
+
+	return explanation, nil
+}
+
+// lookupKnownClient returns the client for the given IP without triggering any hostname or vendor lookups.
+func (s *DNSServer) lookupKnownClient(clientIP string) *model.Client {
+	ip, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return nil
+	}
+
+	if loaded, ok := s.clientIPCache.Load(ip); ok {
+		if client, ok := loaded.(*model.Client); ok {
+			return client
+		}
+	}
+
+	client, err := s.RequestService.FetchClient(ip.String())
+	if err != nil {
+		return nil
+	}
+	return client
+}
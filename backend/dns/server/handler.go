@@ -4,14 +4,17 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"goaway/backend/database"
 	arp "goaway/backend/dns"
 	model "goaway/backend/dns/server/models"
+	"goaway/backend/dnstap"
+	"goaway/backend/metrics"
 	"goaway/backend/notification"
 	"net"
 	"net/netip"
 	"os"
-	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -54,14 +57,25 @@ func (s *DNSServer) shouldBlockQuery(client *model.Client, domainName, fullName
 		return false
 	}
 
-	return !s.Config.DNS.Status.Paused &&
-		s.BlacklistService.IsBlacklisted(domainName) &&
-		!s.WhitelistService.IsWhitelisted(fullName)
+	if s.Config.DNS.Status.Paused || s.WhitelistService.IsWhitelisted(fullName) {
+		return false
+	}
+
+	if s.BlacklistService.IsBlacklisted(domainName) {
+		return true
+	}
+
+	_, blockedByBundle := s.BundleService.BlockedBy(domainName)
+	return blockedByBundle
 }
 
 func (s *DNSServer) processQuery(request *Request) model.RequestLogEntry {
 	domainName := trimDomainDot(request.QName())
 
+	if z, found := s.ZoneService.Find(request.QName()); found {
+		return s.handleAuthoritative(request, z)
+	}
+
 	if isPTRQuery(request, domainName) {
 		return s.handlePTRQuery(request)
 	}
@@ -76,6 +90,12 @@ func (s *DNSServer) processQuery(request *Request) model.RequestLogEntry {
 		return s.handleBlacklisted(request)
 	}
 
+	if s.shouldEnforceSafeSearch(request.Client) {
+		if target, found := safeSearchTarget(domainName); found {
+			return s.handleSafeSearch(request, target)
+		}
+	}
+
 	if isLocalLookup(domainName) {
 		val, err := s.LocalForwardLookup(request)
 		if err != nil {
@@ -99,6 +119,8 @@ func (s *DNSServer) reverseHostnameLookup(requestedHostname string) (netip.Addr,
 	return netip.Addr{}, false
 }
 
+// getClientInfo returns the client using clientIP. Unknown clients are answered with a provisional client
+// right away, while their device, hostname and vendor are looked up in the background.
 func (s *DNSServer) getClientInfo(clientIP netip.Addr) *model.Client {
 	var isLoopback = clientIP.IsLoopback()
 	if isLoopback {
@@ -111,50 +133,67 @@ func (s *DNSServer) getClientInfo(clientIP netip.Addr) *model.Client {
 	}
 
 	if loaded, ok := s.clientIPCache.Load(clientIP); ok {
-		if client, ok := loaded.(*model.Client); ok {
+		if client, ok := loaded.(*model.Client); ok && !addressReassigned(client) {
 			return client
 		}
 	}
 
-	macAddress := arp.GetMacAddress(clientIP)
-	hostname := s.resolveHostname(clientIP)
-
-	if isLoopback {
-		if h, err := os.Hostname(); err == nil {
-			hostname = h
+	// Cached until enriched, so that the following queries don't queue the client again. It is stored
+	// before being queued, as a worker may replace it before enqueue returns.
+	client := s.provisionalClient(clientIP, isLoopback)
+	previous, cached := s.clientIPCache.Swap(client.IP, client)
+	if !s.enrichment.enqueue(enrichmentJob{client: client, loopback: isLoopback}) {
+		if cached {
+			s.clientIPCache.CompareAndSwap(client.IP, client, previous)
 		} else {
-			hostname = "localhost"
+			s.clientIPCache.CompareAndDelete(client.IP, client)
 		}
-	}
-
-	vendor := s.lookupVendor(clientIP.String(), macAddress)
-	client := &model.Client{
-		IP:       clientIP,
-		LastSeen: time.Now(),
-		Name:     hostname,
-		Mac:      macAddress,
-		Vendor:   vendor,
-		Bypass:   false,
+		return client
 	}
 
 	log.Debug("Saving new client: %s", client.IP)
-	_ = s.PopulateClientCaches()
 
 	return client
 }
 
+// findDevice returns the stored device for macAddress, recording the address it is now using.
+func (s *DNSServer) findDevice(clientIP netip.Addr, macAddress string) *database.MacAddress {
+	if macAddress == "" || macAddress == unknownHostname {
+		return nil
+	}
+
+	device, err := s.MACService.FindDevice(macAddress)
+	if err != nil {
+		log.Warning("Could not look up device %s, %v", macAddress, err)
+		return nil
+	}
+
+	if device != nil && device.IP != clientIP.String() {
+		log.Info("Client %s moved from %s to %s", macAddress, device.IP, clientIP)
+	}
+	if device == nil || device.IP != clientIP.String() {
+		s.MACService.SaveMac(clientIP.String(), macAddress, "")
+	}
+
+	return device
+}
+
+// addressReassigned reports whether the address of a cached client is now used by another device.
+func addressReassigned(client *model.Client) bool {
+	if client.Mac == "" || client.Mac == unknownHostname {
+		return false
+	}
+
+	current := arp.GetMacAddress(client.IP)
+	return current != unknownHostname && current != client.Mac
+}
+
 func (s *DNSServer) lookupVendor(clientIP, macAddress string) string {
 	if macAddress == unknownHostname {
 		return ""
 	}
 
-	vendor, err := s.MACService.FindVendor(macAddress)
-	if err == nil && vendor != "" {
-		return vendor
-	}
-
-	log.Debug("Lookup vendor for mac %s", macAddress)
-	vendor, err = arp.GetMacVendor(macAddress)
+	vendor, err := s.MACService.LookupVendor(clientIP, macAddress)
 	if err != nil {
 		log.Debug(
 			"Was not able to find vendor for addr '%s' with MAC '%s'. %v",
@@ -163,7 +202,6 @@ func (s *DNSServer) lookupVendor(clientIP, macAddress string) string {
 		return ""
 	}
 
-	s.MACService.SaveMac(clientIP, macAddress, vendor)
 	return vendor
 }
 
@@ -175,40 +213,9 @@ func (s *DNSServer) resolveHostname(clientIP netip.Addr) string {
 		}
 	}
 
-	if hostname := s.reverseDNSLookup(clientIP); hostname != unknownHostname {
-		return hostname
-	}
-
-	if hostname := s.avahiLookup(clientIP); hostname != unknownHostname {
-		return hostname
-	}
-
-	if hostname := s.sshBannerLookup(clientIP); hostname != unknownHostname {
-		return hostname
-	}
-
-	return unknownHostname
-}
-
-func (s *DNSServer) avahiLookup(clientIP netip.Addr) string {
-	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "avahi-resolve-address", clientIP.String())
-	output, err := cmd.Output()
-	if err == nil {
-		lines := strings.SplitSeq(string(output), "\n")
-		for line := range lines {
-			if strings.Contains(line, clientIP.String()) {
-				parts := strings.Fields(line)
-				if len(parts) >= 2 {
-					hostname := strings.TrimSuffix(parts[1], ".local")
-					if hostname != "" && hostname != clientIP.String() {
-						log.Debug("Found hostname via avahi-resolve: %s -> %s", clientIP, hostname)
-						return hostname
-					}
-				}
-			}
+	for _, lookup := range s.hostnameLookups() {
+		if hostname := lookup(clientIP); hostname != unknownHostname {
+			return hostname
 		}
 	}
 
@@ -305,6 +312,12 @@ func getLocalIP() (netip.Addr, error) {
 }
 
 func (s *DNSServer) handlePTRQuery(request *Request) model.RequestLogEntry {
+	if request.QType() == dns.TypePTR {
+		if answers, found := s.ResolutionService.Lookup(request.QName(), dns.TypePTR, uint32(s.Config.DNS.CacheTTL)); found {
+			return s.respondWithLocalRecords(request, answers)
+		}
+	}
+
 	ipParts := strings.TrimSuffix(request.QName(), ".in-addr.arpa.")
 	parts := strings.Split(ipParts, ".")
 
@@ -389,6 +402,27 @@ func (s *DNSServer) respondWithLocalhost(request *Request) model.RequestLogEntry
 	}
 }
 
+func (s *DNSServer) respondWithLocalRecords(request *Request, answers []dns.RR) model.RequestLogEntry {
+	request.Msg.Response = true
+	request.Msg.Authoritative = false
+	request.Msg.RecursionAvailable = true
+	request.Msg.Rcode = dns.RcodeSuccess
+	request.Msg.Answer = answers
+
+	request.Respond(s.NotificationService)
+	return model.RequestLogEntry{
+		Timestamp:         request.Sent,
+		Domain:            request.QName(),
+		Status:            dnsutil.CodeToString(dns.RcodeSuccess),
+		IP:                []model.ResolvedIP{},
+		ResponseTime:      time.Since(request.Sent),
+		ClientInfo:        request.Client,
+		QueryType:         request.QTypeStr(),
+		ResponseSizeBytes: request.Msg.Len(),
+		Protocol:          request.Protocol,
+	}
+}
+
 func (s *DNSServer) respondWithHostnameA(request *Request, hostIP netip.Addr) model.RequestLogEntry {
 	request.Msg.Response = true
 	request.Msg.Authoritative = false
@@ -672,13 +706,14 @@ func (s *DNSServer) Resolve(req *Request) ([]dns.RR, bool, string) {
 	cacheKey := req.Question.Header().Name + ":" + req.QTypeStr()
 	if cached, found := s.DomainCache.Load(cacheKey); found {
 		if ipAddresses, valid := s.getCachedRecord(cached); valid {
+			metrics.ObserveCacheLookup(true)
 			return ipAddresses, true, dnsutil.CodeToString(dns.RcodeSuccess)
 		}
 	}
+	metrics.ObserveCacheLookup(false)
 
-	if answers, ttl, status := s.resolveResolution(req.Question.Header().Name); len(answers) > 0 {
-		s.CacheRecord(cacheKey, req.Question.Header().Name, answers, ttl)
-		return answers, false, status
+	if answers, found := s.resolveLocalRecords(req, make(map[string]bool)); found {
+		return answers, false, dnsutil.CodeToString(dns.RcodeSuccess)
 	}
 
 	answers, ttl, status := s.resolveCNAMEChain(req, make(map[string]bool))
@@ -688,49 +723,33 @@ func (s *DNSServer) Resolve(req *Request) ([]dns.RR, bool, string) {
 	return answers, false, status
 }
 
-func (s *DNSServer) resolveResolution(domain string) ([]dns.RR, uint32, string) {
-	var (
-		records []dns.RR
-		rr      dns.RR
-		ttl     = uint32(s.Config.DNS.CacheTTL)
-		status  = dnsutil.CodeToString(dns.RcodeSuccess)
-	)
+// resolveLocalRecords answers from the locally configured records, following local CNAMEs to their target.
+// found is true when the name has local records, in which case upstream must not be queried for it.
+func (s *DNSServer) resolveLocalRecords(req *Request, visited map[string]bool) ([]dns.RR, bool) {
+	name := strings.ToLower(req.QName())
+	if visited[name] {
+		log.Warning("Local CNAME loop detected for %s", req.QName())
+		return nil, true
+	}
+	visited[name] = true
 
-	ipFound, err := s.ResolutionService.GetResolution(domain)
-	if err != nil {
-		log.Error("Database lookup error for domain (%s): %v", domain, err)
-		return nil, 0, dnsutil.CodeToString(dns.RcodeServerFailure)
+	answers, found := s.ResolutionService.Lookup(req.QName(), req.QType(), uint32(s.Config.DNS.CacheTTL))
+	if !found || req.QType() == dns.TypeCNAME || len(answers) != 1 {
+		return answers, found
 	}
 
-	if ipFound == "" {
-		status = dnsutil.CodeToString(dns.RcodeNameError)
-	} else {
-		ip, err := netip.ParseAddr(ipFound)
-		if err != nil {
-			log.Warning("Failed to parse IP from database for domain '%s': %v", domain, err)
-			return nil, 0, dnsutil.CodeToString(dns.RcodeServerFailure)
-		}
-		if ip.Is4() {
-			rr = &dns.A{
-				Hdr: dns.Header{
-					Name:  dnsutil.Fqdn(domain),
-					TTL:   ttl,
-					Class: dns.ClassINET,
-				},
-				A: rdata.A{Addr: ip}}
-		} else {
-			rr = &dns.AAAA{
-				Hdr: dns.Header{
-					Name:  dnsutil.Fqdn(domain),
-					TTL:   ttl,
-					Class: dns.ClassINET,
-				},
-				AAAA: rdata.AAAA{Addr: ip},
-			}
-		}
-		records = append(records, rr)
+	cname, ok := answers[0].(*dns.CNAME)
+	if !ok {
+		return answers, found
 	}
-	return records, ttl, status
+
+	target := newSubRequest(req, cname.Target)
+	if targetAnswers, targetFound := s.resolveLocalRecords(target, visited); targetFound {
+		return append(answers, targetAnswers...), true
+	}
+
+	targetAnswers, _, _ := s.Resolve(target)
+	return append(answers, targetAnswers...), true
 }
 
 func (s *DNSServer) resolveCNAMEChain(req *Request, visited map[string]bool) ([]dns.RR, uint32, string) {
@@ -778,15 +797,27 @@ func (s *DNSServer) QueryUpstream(req *Request) ([]dns.RR, uint32, string) {
 		log.Debug("Querying %s, with type %s using '%s' as upstream", req.Question.Header().Name, req.QTypeStr(), upstream)
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
+
+		// The query is packed up front for dnstap, the response is read into the same buffer
+		tap := s.dnstap.Load()
+		sent := time.Now()
+		if tap != nil {
+			if err := upstreamMsg.Pack(); err == nil {
+				tapForwarder(tap, dnstap.ForwarderQuery, proto, upstream, sent, slices.Clone(upstreamMsg.Data))
+			}
+		}
+
 		in, _, err := s.dnsClient.Exchange(ctx, upstreamMsg, proto, upstream)
+		if err == nil && in == nil {
+			err = fmt.Errorf("nil response from upstream")
+		}
+		metrics.ObserveUpstream(upstream, time.Since(sent), err)
 		if err != nil {
 			errCh <- err
 			return
 		}
-
-		if in == nil {
-			errCh <- fmt.Errorf("nil response from upstream")
-			return
+		if tap != nil {
+			tapForwarder(tap, dnstap.ForwarderResponse, proto, upstream, sent, slices.Clone(in.Data))
 		}
 
 		resultCh <- in
@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/settings"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	LogOverflowDropOldest = "dropOldest"
+	LogOverflowDropNewest = "dropNewest"
+	LogOverflowSpill      = "spill"
+
+	defaultLogQueueSize = 1000
+
+	// Entries are dropped once this much is waiting on disk
+	maxSpillSize = 64 << 20
+)
+
+var logSpillPath = filepath.Join("data", "query-log.spill")
+
+// LogQueueStats describes the queue of log entries waiting to be saved.
+type LogQueueStats struct {
+	Depth            int           `json:"depth"`
+	Capacity         int           `json:"capacity"`
+	Dropped          uint64        `json:"dropped"`
+	Spilled          uint64        `json:"spilled"`
+	SpillBytes       int64         `json:"spillBytes"`
+	LastFlushSize    int           `json:"lastFlushSize"`
+	LastFlushLatency time.Duration `json:"lastFlushLatencyNS"`
+}
+
+// logQueue buffers log entries between answering queries and saving them, so that a slow database never
+// holds up a query. What happens when it is full is decided by the overflow policy.
+type logQueue struct {
+	entries chan model.RequestLogEntry
+	spill   spillFile
+
+	dropped          atomic.Uint64
+	spilled          atomic.Uint64
+	lastFlushSize    atomic.Int64
+	lastFlushLatency atomic.Int64
+}
+
+func newLogQueue(size int, spillPath string) *logQueue {
+	if size <= 0 {
+		size = defaultLogQueueSize
+	}
+	return &logQueue{
+		entries: make(chan model.RequestLogEntry, size),
+		spill:   spillFile{path: spillPath},
+	}
+}
+
+// ValidateQueryLog reports whether config holds a valid query log overflow policy.
+func ValidateQueryLog(config settings.QueryLogConfig) error {
+	switch config.Overflow {
+	case "", LogOverflowDropOldest, LogOverflowDropNewest, LogOverflowSpill:
+		return nil
+	default:
+		return fmt.Errorf("unknown query log overflow '%s', expected '%s', '%s' or '%s'",
+			config.Overflow, LogOverflowDropOldest, LogOverflowDropNewest, LogOverflowSpill)
+	}
+}
+
+// push queues entry without ever blocking.
+func (q *logQueue) push(entry model.RequestLogEntry, overflow string) {
+	select {
+	case q.entries <- entry:
+		return
+	default:
+	}
+
+	switch overflow {
+	case LogOverflowDropNewest:
+		q.drop()
+	case LogOverflowSpill:
+		if err := q.spill.write(entry); err != nil {
+			log.Debug("Could not spill log entry to disk: %v", err)
+			q.drop()
+			return
+		}
+		if q.spilled.Add(1) == 1 {
+			log.Warning("Query log queue is full, spilling entries to %s", q.spill.path)
+		}
+	default:
+		select {
+		case <-q.entries:
+			q.drop()
+		default:
+		}
+		select {
+		case q.entries <- entry:
+		default:
+			q.drop()
+		}
+	}
+}
+
+func (q *logQueue) drop() {
+	if q.dropped.Add(1) == 1 {
+		log.Warning("Query log queue is full, dropping entries")
+	}
+}
+
+func (q *logQueue) recordFlush(size int, latency time.Duration) {
+	q.lastFlushSize.Store(int64(size))
+	q.lastFlushLatency.Store(int64(latency))
+}
+
+func (q *logQueue) stats() LogQueueStats {
+	return LogQueueStats{
+		Depth:            len(q.entries),
+		Capacity:         cap(q.entries),
+		Dropped:          q.dropped.Load(),
+		Spilled:          q.spilled.Load(),
+		SpillBytes:       q.spill.pending(),
+		LastFlushSize:    int(q.lastFlushSize.Load()),
+		LastFlushLatency: time.Duration(q.lastFlushLatency.Load()),
+	}
+}
+
+// spillFile holds log entries that did not fit in the queue as JSON lines, until they are read back.
+type spillFile struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (f *spillFile) write(entry model.RequestLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size+int64(len(line)) > maxSpillSize {
+		return errors.New("spill file is full")
+	}
+	if f.file == nil {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		f.file = file
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	return err
+}
+
+func (f *spillFile) pending() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+// drain reads back spilled entries, including ones left over from a previous run, passing them to save
+// in batches of up to size entries.
+func (f *spillFile) drain(size int, save func([]model.RequestLogEntry)) error {
+	draining := f.path + ".draining"
+
+	// Entries keep being spilled while draining, so the file is moved out of the way first
+	f.mu.Lock()
+	if _, err := os.Stat(draining); errors.Is(err, os.ErrNotExist) {
+		if f.file != nil {
+			_ = f.file.Close()
+			f.file = nil
+		}
+		if err := os.Rename(f.path, draining); err != nil && !errors.Is(err, os.ErrNotExist) {
+			f.mu.Unlock()
+			return fmt.Errorf("failed to move spill file: %w", err)
+		}
+		f.size = 0
+	}
+	f.mu.Unlock()
+
+	file, err := os.Open(draining)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+
+	var batch []model.RequestLogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry model.RequestLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Debug("Skipping invalid spilled log entry: %v", err)
+			continue
+		}
+		batch = append(batch, entry)
+		if len(batch) >= size {
+			save(batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		save(batch)
+	}
+
+	// Removed even when it could not be read completely, as reading it again would save entries twice
+	scanErr := scanner.Err()
+	_ = file.Close()
+	if err := os.Remove(draining); err != nil {
+		return fmt.Errorf("failed to remove spill file: %w", err)
+	}
+	if scanErr != nil {
+		return fmt.Errorf("failed to read spill file: %w", scanErr)
+	}
+	return nil
+}
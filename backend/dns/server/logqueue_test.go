@@ -0,0 +1,65 @@
+package server
+
+import (
+	model "goaway/backend/dns/server/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogQueueOverflow(t *testing.T) {
+	tests := []struct {
+		overflow string
+		want     []string
+	}{
+		{LogOverflowDropOldest, []string{"b.com", "c.com"}},
+		{LogOverflowDropNewest, []string{"a.com", "b.com"}},
+	}
+
+	for _, tt := range tests {
+		q := newLogQueue(2, filepath.Join(t.TempDir(), "spill"))
+		for _, domain := range []string{"a.com", "b.com", "c.com"} {
+			q.push(model.RequestLogEntry{Domain: domain}, tt.overflow)
+		}
+
+		if stats := q.stats(); stats.Dropped != 1 || stats.Depth != 2 {
+			t.Errorf("%s: dropped %d with depth %d, want 1 and 2", tt.overflow, stats.Dropped, stats.Depth)
+		}
+		for _, want := range tt.want {
+			if got := (<-q.entries).Domain; got != want {
+				t.Errorf("%s: got %s, want %s", tt.overflow, got, want)
+			}
+		}
+	}
+}
+
+func TestLogQueueSpillsToDisk(t *testing.T) {
+	q := newLogQueue(1, filepath.Join(t.TempDir(), "spill"))
+	for _, domain := range []string{"a.com", "b.com", "c.com"} {
+		q.push(model.RequestLogEntry{Domain: domain}, LogOverflowSpill)
+	}
+
+	stats := q.stats()
+	if stats.Dropped != 0 || stats.Spilled != 2 || stats.SpillBytes == 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	var saved []string
+	err := q.spill.drain(1, func(entries []model.RequestLogEntry) {
+		for _, entry := range entries {
+			saved = append(saved, entry.Domain)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[0] != "b.com" || saved[1] != "c.com" {
+		t.Errorf("read back %v, want [b.com c.com]", saved)
+	}
+	if q.stats().SpillBytes != 0 {
+		t.Error("expected the spill file to be empty after draining")
+	}
+
+	if err := q.spill.drain(1, func([]model.RequestLogEntry) { t.Error("expected nothing to be read back twice") }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -23,7 +23,7 @@ func (s *DNSServer) ProcessLogEntries(ctx context.Context) {
 				s.saveBatch(batch)
 			}
 			return
-		case entry := <-s.logEntryChannel:
+		case entry := <-s.logQueue.entries:
 			log.Debug("%s", entry.String())
 			if s.WSQueries != nil {
 				entryWSJson, _ := json.Marshal(entry)
@@ -40,17 +40,27 @@ func (s *DNSServer) ProcessLogEntries(ctx context.Context) {
 				s.saveBatch(batch)
 				batch = nil
 			}
+			if err := s.logQueue.spill.drain(batchSize, s.saveBatch); err != nil {
+				log.Warning("Could not read back spilled logs: %v", err)
+			}
 		}
 	}
 }
 
 func (s *DNSServer) saveBatch(entries []model.RequestLogEntry) {
+	start := time.Now()
 	err := s.RequestService.SaveRequestLog(entries)
+	s.logQueue.recordFlush(len(entries), time.Since(start))
 	if err != nil {
 		log.Warning("Error while saving logs, reason: %v", err)
 	}
 }
 
+// LogQueueStats returns the state of the queue of log entries waiting to be saved.
+func (s *DNSServer) LogQueueStats() LogQueueStats {
+	return s.logQueue.stats()
+}
+
 // Removes old log entries based on the configured retention period.
 func (s *DNSServer) ClearOldEntries(ctx context.Context) {
 	const (
@@ -2,13 +2,19 @@ package model
 
 import (
 	"net/netip"
+	"regexp"
 	"time"
 )
 
+var clientIDPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+
 // Client represents a DNS client with associated metadata.
 // It includes the client's IP address, hostname, MAC address, and an ignored flag.'
 // The 'bypass' field indicates whether the client should be allowed to bypass blacklist rules.
+// Clients with a known MAC are identified by it, so their name and bypass setting survive address changes.
+// DoH and DoT clients can instead present a client ID, which identifies them regardless of their address.
 type Client struct {
+	ID       string     `json:"clientId,omitempty"`
 	IP       netip.Addr `json:"ip"`
 	LastSeen time.Time  `json:"lastSeen"`
 	Name     string     `json:"name"`
@@ -16,3 +22,8 @@ type Client struct {
 	Vendor   string     `json:"vendor"`
 	Bypass   bool       `json:"bypass"`
 }
+
+// ValidClientID reports whether id can be used as a client ID, which must be a single lowercase DNS label.
+func ValidClientID(id string) bool {
+	return clientIDPattern.MatchString(id)
+}
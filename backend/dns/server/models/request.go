@@ -46,6 +46,7 @@ const (
 	TCP Protocol = "TCP"
 	DoT Protocol = "DoT"
 	DoH Protocol = "DoH"
+	DoQ Protocol = "DoQ"
 )
 
 type ResolvedIP struct {
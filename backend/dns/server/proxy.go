@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseTrustedProxies parses the IPs and CIDR ranges of the reverse proxies trusted to report client addresses.
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy range '%s': %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s': %w", entry, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+func isTrustedProxy(ip netip.Addr, trusted []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustForwardedHeaders replaces the address of requests sent by a trusted proxy with the client address it reports,
+// so that DoH clients behind a reverse proxy are identified by their own address.
+func trustForwardedHeaders(next http.Handler, trusted []netip.Prefix) http.Handler {
+	if len(trusted) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip, ok := forwardedClientIP(r, trusted); ok {
+			r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClientIP returns the client address reported in the Forwarded header, or in X-Forwarded-For
+// when there is none. Addresses are read from the nearest hop backwards, skipping trusted proxies, as every
+// address before the first untrusted one may have been forged by the client.
+func forwardedClientIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !isTrustedProxy(peer.Addr(), trusted) {
+		return netip.Addr{}, false
+	}
+
+	hops := forwardedFor(r.Header.Values("Forwarded"))
+	if len(hops) == 0 {
+		hops = forwardedHops(r.Header.Values("X-Forwarded-For"))
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := parseForwardedAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = ip
+		if !isTrustedProxy(ip, trusted) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// forwardedFor returns the "for" parameters of Forwarded headers (RFC 7239), nearest hop last.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range forwardedHops(values) {
+		for pair := range strings.SplitSeq(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(key, "for") {
+				hops = append(hops, value)
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedHops splits comma separated header values into hops, nearest hop last.
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for hop := range strings.SplitSeq(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseForwardedAddr parses a hop such as "192.0.2.1", "192.0.2.1:4711" or "\"[2001:db8::1]:4711\"".
+func parseForwardedAddr(hop string) (netip.Addr, error) {
+	hop = strings.Trim(hop, `"`)
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+
+	ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid forwarded address '%s'", hop)
+	}
+	return ip.Unmap(), nil
+}
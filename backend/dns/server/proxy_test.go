@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwardedClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		value      string
+		want       string
+	}{
+		{"untrusted peer", "203.0.113.9:4000", "X-Forwarded-For", "198.51.100.7", ""},
+		{"x-forwarded-for", "10.0.0.2:4000", "X-Forwarded-For", "198.51.100.7", "198.51.100.7"},
+		{"forged hops are ignored", "10.0.0.2:4000", "X-Forwarded-For", "1.1.1.1, 198.51.100.7, 10.0.0.3", "198.51.100.7"},
+		{"forwarded", "192.0.2.1:4000", "Forwarded", `for=198.51.100.7;proto=https, for="10.0.0.3:80"`, "198.51.100.7"},
+		{"forwarded ipv6", "192.0.2.1:4000", "Forwarded", `for="[2001:db8::1]:4711"`, "2001:db8::1"},
+		{"obfuscated", "10.0.0.2:4000", "Forwarded", "for=_hidden", ""},
+		{"no header", "10.0.0.2:4000", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/dns-query", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+
+		ip, ok := forwardedClientIP(r, trusted)
+		got := ""
+		if ok {
+			got = ip.String()
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := parseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+	if _, err := parseTrustedProxies([]string{"proxy.lan"}); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"goaway/backend/dns/ratelimit"
+	"goaway/backend/notification"
+	"goaway/backend/settings"
+	"net/netip"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+)
+
+// Minimum time between notifications about the same subnet being throttled
+const throttleNotificationInterval = 10 * time.Minute
+
+// ValidateRateLimits reports whether config holds valid rate limits.
+func ValidateRateLimits(config settings.DNSRateLimitConfig) error {
+	if config.QueriesPerSecond < 0 || config.ResponsesPerSecond < 0 || config.Burst < 0 || config.Slip < 0 {
+		return errors.New("rate limits can't be negative")
+	}
+	if config.IPv4Prefix < 0 || config.IPv4Prefix > 32 || config.IPv6Prefix < 0 || config.IPv6Prefix > 128 {
+		return fmt.Errorf("invalid rate limit prefix lengths /%d and /%d", config.IPv4Prefix, config.IPv6Prefix)
+	}
+	return nil
+}
+
+// ReloadRateLimits applies the query and response rate limits of the current settings.
+func (s *DNSServer) ReloadRateLimits() error {
+	config := s.Config.DNS.RateLimit
+	if err := ValidateRateLimits(config); err != nil {
+		return err
+	}
+
+	var queries *ratelimit.Limiter
+	if config.Enabled && config.QueriesPerSecond > 0 {
+		queries = ratelimit.NewLimiter(config.QueriesPerSecond, config.Burst, config.IPv4Prefix, config.IPv6Prefix)
+	}
+	s.queryLimiter.Store(queries)
+
+	var responses *ratelimit.ResponseLimiter
+	if config.Enabled && config.ResponsesPerSecond > 0 {
+		responses = ratelimit.NewResponseLimiter(config.ResponsesPerSecond, config.Slip, config.IPv4Prefix, config.IPv6Prefix)
+	}
+	s.responseLimiter.Store(responses)
+
+	return nil
+}
+
+// RateLimited returns the number of queries refused and responses slipped or dropped by the rate limits.
+func (s *DNSServer) RateLimited() (queries uint64, responses uint64) {
+	if limiter := s.queryLimiter.Load(); limiter != nil {
+		queries = limiter.Limited()
+	}
+	if limiter := s.responseLimiter.Load(); limiter != nil {
+		responses = limiter.Limited()
+	}
+	return queries, responses
+}
+
+// checkRateLimit reports whether the client is within its query rate limit, answering REFUSED when it is not.
+func (s *DNSServer) checkRateLimit(w dns.ResponseWriter, r *dns.Msg, tap clientTap, clientIP netip.Addr) bool {
+	limiter := s.queryLimiter.Load()
+	if limiter == nil {
+		return true
+	}
+
+	allowed, first := limiter.Allow(clientIP)
+	if allowed {
+		return true
+	}
+
+	if first {
+		s.notifyThrottled(limiter.Subnet(clientIP))
+	}
+	refuse(w, r, tap, clientIP)
+	return false
+}
+
+func (s *DNSServer) notifyThrottled(subnet netip.Prefix) {
+	now := time.Now()
+	if last, ok := s.throttleNotified.Load(subnet); ok && now.Sub(last.(time.Time)) < throttleNotificationInterval {
+		return
+	}
+	s.throttleNotified.Store(subnet, now)
+
+	log.Warning("Client %s exceeded %.0f queries per second and is being throttled", subnet, s.Config.DNS.RateLimit.QueriesPerSecond)
+	s.NotificationService.SendNotification(
+		notification.SeverityWarning,
+		notification.CategoryDNS,
+		fmt.Sprintf("Client %s is sending more than %.0f queries per second and is being throttled", subnet, s.Config.DNS.RateLimit.QueriesPerSecond),
+	)
+}
+
+// limitResponse applies response rate limiting to UDP responses. It reports whether the response should be
+// sent, truncating it when it is slipped.
+func (r *Request) limitResponse() bool {
+	if r.responseLimiter == nil {
+		return true
+	}
+
+	switch r.responseLimiter.Check(r.Client.IP, responseToken(r.Msg)) {
+	case ratelimit.Slip:
+		r.Msg.Truncated = true
+		r.Msg.Answer, r.Msg.Ns, r.Msg.Extra = nil, nil, nil
+		return true
+	case ratelimit.Drop:
+		return false
+	default:
+		return true
+	}
+}
+
+// responseToken identifies identical responses. Errors are grouped by their code, so that queries
+// for random names are limited together.
+func responseToken(msg *dns.Msg) string {
+	if msg.Rcode != dns.RcodeSuccess {
+		return "rcode/" + dns.RcodeToString[msg.Rcode]
+	}
+	return strings.ToLower(msg.Question[0].Header().Name) + "/" + dns.TypeToString[dns.RRToType(msg.Question[0])]
+}
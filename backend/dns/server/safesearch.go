@@ -0,0 +1,133 @@
+package server
+
+import (
+	model "goaway/backend/dns/server/models"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/rdata"
+)
+
+const safeSearchTTL = 300
+
+// Hostnames published by each provider which always serve results with safe-search or restricted mode enforced.
+// Answering with a CNAME to these keeps working when the providers change the IPs behind them.
+var safeSearchTargets = map[string]string{
+	"www.bing.com": "strict.bing.com.",
+
+	"duckduckgo.com":       "safe.duckduckgo.com.",
+	"www.duckduckgo.com":   "safe.duckduckgo.com.",
+	"start.duckduckgo.com": "safe.duckduckgo.com.",
+
+	"www.youtube.com":          "restrict.youtube.com.",
+	"m.youtube.com":            "restrict.youtube.com.",
+	"youtubei.googleapis.com":  "restrict.youtube.com.",
+	"youtube.googleapis.com":   "restrict.youtube.com.",
+	"www.youtube-nocookie.com": "restrict.youtube.com.",
+}
+
+// Google serves search from a domain per country, e.g. google.com, google.de and google.co.uk
+var googleSearchDomain = regexp.MustCompile(`^(www\.)?google\.([a-z]{2,3}|com?\.[a-z]{2})$`)
+
+func safeSearchTarget(domainName string) (string, bool) {
+	domainName = strings.ToLower(domainName)
+
+	if target, found := safeSearchTargets[domainName]; found {
+		return target, true
+	}
+
+	if googleSearchDomain.MatchString(domainName) {
+		return "forcesafesearch.google.com.", true
+	}
+
+	return "", false
+}
+
+// shouldEnforceSafeSearch reports whether safe-search applies to the client.
+// When no clients are configured it applies to every client.
+func (s *DNSServer) shouldEnforceSafeSearch(client *model.Client) bool {
+	config := s.Config.DNS.SafeSearch
+	if !config.Enabled {
+		return false
+	}
+	if len(config.Clients) == 0 {
+		return true
+	}
+
+	for _, entry := range config.Clients {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				log.Warning("Invalid safe-search client range '%s': %v", entry, err)
+				continue
+			}
+			if prefix.Contains(client.IP) {
+				return true
+			}
+			continue
+		}
+
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			log.Warning("Invalid safe-search client '%s': %v", entry, err)
+			continue
+		}
+		if ip == client.IP {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleSafeSearch answers with a CNAME to the enforced hostname, followed by the records resolved for it.
+func (s *DNSServer) handleSafeSearch(request *Request, target string) model.RequestLogEntry {
+	answers, cached, status := s.Resolve(newSubRequest(request, target))
+
+	cname := &dns.CNAME{
+		Hdr: dns.Header{
+			Name:  request.QName(),
+			TTL:   safeSearchTTL,
+			Class: dns.ClassINET,
+		},
+		CNAME: rdata.CNAME{Target: target},
+	}
+
+	request.Msg.Answer = append([]dns.RR{cname}, answers...)
+	request.Msg.Response = true
+	request.Msg.Authoritative = false
+	request.Msg.RecursionAvailable = true
+	if rcode, ok := dns.StringToRcode[status]; ok {
+		request.Msg.Rcode = rcode
+	} else {
+		request.Msg.Rcode = dns.RcodeServerFailure
+	}
+
+	resolved := make([]model.ResolvedIP, 0, len(answers))
+	for _, answer := range answers {
+		switch rr := answer.(type) {
+		case *dns.A:
+			resolved = append(resolved, model.ResolvedIP{IP: rr.Addr, RType: "A"})
+		case *dns.AAAA:
+			resolved = append(resolved, model.ResolvedIP{IP: rr.Addr, RType: "AAAA"})
+		}
+	}
+
+	log.Debug("Enforcing safe-search for %s, answering with %s", request.QName(), target)
+	request.Respond(s.NotificationService)
+	return model.RequestLogEntry{
+		Domain:            request.QName(),
+		Status:            status,
+		QueryType:         request.QTypeStr(),
+		IP:                resolved,
+		ResponseSizeBytes: request.Msg.Len(),
+		Timestamp:         request.Sent,
+		ResponseTime:      time.Since(request.Sent),
+		Cached:            cached,
+		ClientInfo:        request.Client,
+		Protocol:          request.Protocol,
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/settings"
+	"net/netip"
+	"testing"
+)
+
+func TestSafeSearchTarget(t *testing.T) {
+	tests := []struct {
+		domain    string
+		want      string
+		wantFound bool
+	}{
+		{"google.com", "forcesafesearch.google.com.", true},
+		{"www.google.de", "forcesafesearch.google.com.", true},
+		{"google.co.uk", "forcesafesearch.google.com.", true},
+		{"WWW.Google.COM", "forcesafesearch.google.com.", true},
+		{"www.youtube.com", "restrict.youtube.com.", true},
+		{"youtubei.googleapis.com", "restrict.youtube.com.", true},
+		{"www.bing.com", "strict.bing.com.", true},
+		{"duckduckgo.com", "safe.duckduckgo.com.", true},
+		{"mail.google.com", "", false},
+		{"google.example.com", "", false},
+		{"notgoogle.com", "", false},
+		{"youtube.com.evil.net", "", false},
+		{"example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := safeSearchTarget(tt.domain)
+		if got != tt.want || found != tt.wantFound {
+			t.Errorf("safeSearchTarget(%s) = %q, %v, want %q, %v", tt.domain, got, found, tt.want, tt.wantFound)
+		}
+	}
+}
+
+func TestShouldEnforceSafeSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config settings.SafeSearchConfig
+		ip     string
+		want   bool
+	}{
+		{"disabled", settings.SafeSearchConfig{Clients: []string{"192.168.1.0/24"}}, "192.168.1.10", false},
+		{"no clients applies to everyone", settings.SafeSearchConfig{Enabled: true}, "203.0.113.5", true},
+		{"inside range", settings.SafeSearchConfig{Enabled: true, Clients: []string{"192.168.1.128/25"}}, "192.168.1.200", true},
+		{"outside range", settings.SafeSearchConfig{Enabled: true, Clients: []string{"192.168.1.128/25"}}, "192.168.1.10", false},
+		{"single ip", settings.SafeSearchConfig{Enabled: true, Clients: []string{"192.168.1.20"}}, "192.168.1.20", true},
+		{"other ip", settings.SafeSearchConfig{Enabled: true, Clients: []string{"192.168.1.20"}}, "192.168.1.21", false},
+		{"ipv6 range", settings.SafeSearchConfig{Enabled: true, Clients: []string{"2001:db8::/32"}}, "2001:db8::1", true},
+		{"invalid entries are skipped", settings.SafeSearchConfig{Enabled: true, Clients: []string{"bogus", "10.0.0.0/33", "10.0.0.1"}}, "10.0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		server := &DNSServer{Config: &settings.Config{DNS: settings.DNSConfig{SafeSearch: tt.config}}}
+		client := &model.Client{IP: netip.MustParseAddr(tt.ip)}
+		if got := server.shouldEnforceSafeSearch(client); got != tt.want {
+			t.Errorf("%s: shouldEnforceSafeSearch(%s) = %v, want %v", tt.name, tt.ip, got, tt.want)
+		}
+	}
+}
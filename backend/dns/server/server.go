@@ -8,19 +8,27 @@ import (
 	"goaway/backend/alert"
 	"goaway/backend/audit"
 	"goaway/backend/blacklist"
+	"goaway/backend/bundle"
+	"goaway/backend/dhcp"
+	"goaway/backend/dns/ratelimit"
 	model "goaway/backend/dns/server/models"
+	"goaway/backend/dnstap"
 	"goaway/backend/logging"
 	"goaway/backend/mac"
+	"goaway/backend/metrics"
 	"goaway/backend/notification"
 	"goaway/backend/request"
 	"goaway/backend/resolution"
 	"goaway/backend/settings"
+	"goaway/backend/syslog"
 	"goaway/backend/user"
 	"goaway/backend/whitelist"
+	"goaway/backend/zone"
 	"io"
 	"net"
 	"net/netip"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"codeberg.org/miekg/dns"
@@ -44,8 +52,8 @@ type DNSServer struct {
 	// Application level settings, mostly used for DNS behaviour
 	Config *settings.Config
 
-	// Central channel where processed request log entries are pushed
-	logEntryChannel chan model.RequestLogEntry
+	// Central queue where processed request log entries are pushed
+	logQueue *logQueue
 
 	// Websocket connection used to stream query logs to the web UI
 	WSQueries *websocket.Conn
@@ -63,6 +71,30 @@ type DNSServer struct {
 	// Cache mapping IP -> client info (name, mac) for quick lookup during request processing
 	clientIPCache sync.Map
 
+	// Cache mapping client IDs presented by DoH and DoT clients -> client info
+	clientIDCache sync.Map
+
+	// Looks up hostnames and vendors of new clients in the background
+	enrichment *enrichmentPool
+
+	// Access control lists deciding which clients are answered, replaced when settings change
+	access atomic.Pointer[accessControl]
+
+	// Queries refused or dropped by the access control lists
+	denied deniedQueries
+
+	// Per client query rate limits and response rate limits, nil when disabled
+	queryLimiter    atomic.Pointer[ratelimit.Limiter]
+	responseLimiter atomic.Pointer[ratelimit.ResponseLimiter]
+
+	// Last time a notification was sent about a throttled subnet
+	throttleNotified sync.Map
+
+	// Receives dnstap messages of queries and responses, nil when disabled
+	dnstap       atomic.Pointer[dnstap.Output]
+	dnstapConfig settings.DnstapConfig
+	dnstapLock   sync.Mutex
+
 	// In-memory cache for resolved DNS records to speed up responses and reduce upstream queries
 	DomainCache sync.Map
 
@@ -77,6 +109,12 @@ type DNSServer struct {
 	NotificationService *notification.Service
 	BlacklistService    *blacklist.Service
 	WhitelistService    *whitelist.Service
+	BundleService       *bundle.Service
+	ZoneService         *zone.Service
+	LeaseService        *dhcp.Service
+
+	// Forwards query log entries to a remote syslog server
+	Syslog *syslog.Forwarder
 }
 
 type CachedRecord struct {
@@ -96,6 +134,25 @@ type Request struct {
 	Client         *model.Client
 	Protocol       model.Protocol
 	Prefetch       bool
+
+	// Limits identical responses, only set for UDP queries when response rate limiting is enabled
+	responseLimiter *ratelimit.ResponseLimiter
+
+	// Receives the response as a dnstap message
+	tap clientTap
+}
+
+// newSubRequest creates a request for name with the same type, used when following a CNAME on behalf of request.
+// The returned request has no ResponseWriter and must not be responded to.
+func newSubRequest(request *Request, name string) *Request {
+	msg := dns.NewMsg(name, request.QType())
+	return &Request{
+		Sent:     request.Sent,
+		Msg:      msg,
+		Question: msg.Question[0],
+		Client:   request.Client,
+		Protocol: request.Protocol,
+	}
 }
 
 func (r *Request) QType() uint16 {
@@ -113,10 +170,15 @@ func (r *Request) QName() string {
 // Respond writes the DNS response back to the client.
 // It is the caller's responsibility to call this method, and not write to the ResponseWriter directly, as Respond also handles packing the message and error handling.
 func (r *Request) Respond(ns *notification.Service) {
+	if !r.limitResponse() {
+		return
+	}
+
 	err := r.Msg.Pack()
 	if err != nil {
 		log.Warning("Failed to pack DNS response for '%s': %v", r.Msg.Question[0].Header().Name, err)
 	}
+	r.tap.response(r.Msg.Data)
 	_, err = io.Copy(r.ResponseWriter, r.Msg)
 	if err != nil {
 		log.Warning("Failed to write DNS response for '%s': %v", r.Msg.Question[0].Header().Name, err)
@@ -144,23 +206,31 @@ type communicationMessage struct {
 	DNS      bool   `json:"dns"`
 }
 
-func NewDNSServer(config *settings.Config, dbconn *gorm.DB, cert tls.Certificate) (*DNSServer, error) {
+func NewDNSServer(config *settings.Config, dbconn *gorm.DB) (*DNSServer, error) {
 	server := &DNSServer{
-		Config:          config,
-		dbConn:          dbconn,
-		logEntryChannel: make(chan model.RequestLogEntry, 1000),
-		dnsClient:       dns.NewClient(),
-		DomainCache:     sync.Map{},
+		Config:      config,
+		dbConn:      dbconn,
+		logQueue:    newLogQueue(config.QueryLog.QueueSize, logSpillPath),
+		dnsClient:   dns.NewClient(),
+		DomainCache: sync.Map{},
+	}
+
+	server.enrichment = newEnrichmentPool(enrichmentWorkers, enrichmentQueueSize, server.enrichClient)
+
+	if err := server.ReloadAccessControl(); err != nil {
+		return nil, err
+	}
+	if err := server.ReloadRateLimits(); err != nil {
+		return nil, err
+	}
+	if err := server.ReloadDnstap(); err != nil {
+		return nil, err
 	}
 
 	return server, nil
 }
 
 func (s *DNSServer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) {
-	if !s.validQuery(w, r) {
-		return
-	}
-
 	var clientIP netip.Addr
 
 	switch addr := w.RemoteAddr().(type) {
@@ -182,26 +252,47 @@ func (s *DNSServer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.M
 		return
 	}
 
-	client := s.getClientInfo(clientIP)
 	protocol := s.detectProtocol(ctx, w)
+	tap := clientTap{output: s.dnstap.Load(), w: w, protocol: protocol, sent: time.Now()}
+	// Refused and malformed queries are tapped as well
+	tap.query(r.Data)
 
-	go s.WSCom(communicationMessage{
-		Client:   true,
-		Upstream: false,
-		DNS:      false,
-		IP:       client.IP.String(),
-	})
+	id := s.clientID(ctx, w)
+	if !s.checkAccess(w, r, tap, clientIP, id) || !s.checkRateLimit(w, r, tap, clientIP) || !s.validQuery(w, r, tap) {
+		return
+	}
+
+	var client *model.Client
+	if id != "" {
+		client = s.getClientByID(id, clientIP)
+	} else {
+		client = s.getClientInfo(clientIP)
+	}
 
-	entry := s.processQuery(&Request{
+	request := &Request{
 		ResponseWriter: w,
 		Msg:            r,
 		Question:       r.Question[0],
-		Sent:           time.Now(),
+		Sent:           tap.sent,
 		Client:         client,
 		Prefetch:       false,
 		Protocol:       protocol,
+
+		tap: tap,
+	}
+	if protocol == model.UDP {
+		request.responseLimiter = s.responseLimiter.Load()
+	}
+
+	go s.WSCom(communicationMessage{
+		Client:   true,
+		Upstream: false,
+		DNS:      false,
+		IP:       client.IP.String(),
 	})
 
+	entry := s.processQuery(request)
+
 	go s.WSCom(communicationMessage{
 		Client:   false,
 		Upstream: false,
@@ -209,7 +300,9 @@ func (s *DNSServer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.M
 		IP:       client.IP.String(),
 	})
 
-	s.logEntryChannel <- entry
+	metrics.ObserveQuery(string(entry.Protocol), entry.QueryType, entry.Status, entry.Blocked, entry.Cached, entry.ResponseTime)
+	s.Syslog.Query(entry)
+	s.logQueue.push(entry, s.Config.QueryLog.Overflow)
 }
 
 func (s *DNSServer) detectProtocol(ctx context.Context, w dns.ResponseWriter) model.Protocol {
@@ -218,10 +311,12 @@ func (s *DNSServer) detectProtocol(ctx context.Context, w dns.ResponseWriter) mo
 		return model.DoH
 	}
 
-	if conn, ok := w.(interface{ ConnectionState() *tls.ConnectionState }); ok {
-		if conn.ConnectionState() != nil {
-			return model.DoT
-		}
+	if ctx.Value(model.DoQ) == true {
+		return model.DoQ
+	}
+
+	if _, ok := w.Conn().(*tls.Conn); ok {
+		return model.DoT
 	}
 
 	if conn, ok := w.(interface{ RemoteAddr() net.Addr }); ok {
@@ -242,15 +337,58 @@ func (s *DNSServer) PopulateClientCaches() error {
 		return err
 	}
 
+	s.clientIDCache.Clear()
 	for _, client := range clients {
+		if client.ID != "" {
+			s.clientIDCache.Store(client.ID, &client)
+			continue
+		}
 		s.clientHostnameCache.Store(client.Name, &client)
 		s.clientIPCache.Store(client.IP, &client)
 	}
 
 	log.Debug("Populated client caches with %d client(s)", len(clients))
+	s.ApplyLeases()
 	return nil
 }
 
+// ApplyLeases names clients after their DHCP lease, taking precedence over names found by probing the client.
+func (s *DNSServer) ApplyLeases() {
+	for _, lease := range s.LeaseService.Leases() {
+		if lease.Hostname == "" || s.hasDeviceName(lease.MAC) {
+			continue
+		}
+
+		client := &model.Client{IP: lease.IP}
+		if loaded, ok := s.clientIPCache.Load(lease.IP); ok {
+			if existing, ok := loaded.(*model.Client); ok {
+				copied := *existing
+				client = &copied
+				if existing.Name != lease.Hostname {
+					s.clientHostnameCache.Delete(existing.Name)
+				}
+			}
+		}
+
+		client.Name = lease.Hostname
+		if lease.MAC != "" {
+			client.Mac = lease.MAC
+		}
+
+		s.clientIPCache.Store(client.IP, client)
+		s.clientHostnameCache.Store(client.Name, client)
+	}
+}
+
+// hasDeviceName reports whether the device was given a name, which takes precedence over its lease.
+func (s *DNSServer) hasDeviceName(mac string) bool {
+	if mac == "" {
+		return false
+	}
+	device, err := s.MACService.FindDevice(mac)
+	return err == nil && device != nil && device.Name != ""
+}
+
 func (s *DNSServer) WSCom(message communicationMessage) {
 	if s.WSCommunication == nil {
 		return
@@ -280,16 +418,22 @@ func (s *DNSServer) WSCom(message communicationMessage) {
 	}
 }
 
-func (s *DNSServer) validQuery(w dns.ResponseWriter, r *dns.Msg) bool {
+func (s *DNSServer) validQuery(w dns.ResponseWriter, r *dns.Msg, tap clientTap) bool {
 	failedCallback := func() bool {
+		r.Response = true
 		r.Rcode = dns.RcodeFormatError
+		if err := r.Pack(); err != nil {
+			log.Warning("Failed to pack FORMERR response: %v", err)
+			return false
+		}
+		tap.response(r.Data)
 		_, err := io.Copy(w, r)
 		if err != nil {
-			log.Warning("Failed to write DNS response for '%s': %v", r.Question[0].Header().Name, err)
+			log.Warning("Failed to write FORMERR response: %v", err)
 			s.NotificationService.SendNotification(
 				notification.SeverityWarning,
 				notification.CategoryDNS,
-				fmt.Sprintf("Failed to write DNS response for '%s': %v", r.Question[0].Header().Name, err),
+				fmt.Sprintf("Failed to write FORMERR response: %v", err),
 			)
 		}
 		return false
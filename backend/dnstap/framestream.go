@@ -0,0 +1,79 @@
+package dnstap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Frame Streams control frames, see https://farsightsec.github.io/fstrm/
+const (
+	controlAccept = 0x01
+	controlStart  = 0x02
+	controlStop   = 0x03
+	controlReady  = 0x04
+	controlFinish = 0x05
+
+	controlFieldContentType = 0x01
+
+	contentType = "protobuf:dnstap.Dnstap"
+
+	maxControlFrameSize = 512
+)
+
+// writeControl writes a control frame, which is escaped by a zero length.
+func writeControl(w io.Writer, controlType uint32) error {
+	frame := binary.BigEndian.AppendUint32(nil, controlType)
+	if controlType != controlStop && controlType != controlFinish {
+		frame = binary.BigEndian.AppendUint32(frame, controlFieldContentType)
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(contentType)))
+		frame = append(frame, contentType...)
+	}
+
+	header := binary.BigEndian.AppendUint32(nil, 0)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(frame)))
+	_, err := w.Write(append(header, frame...))
+	return err
+}
+
+// readControl reads a control frame and returns its type. Its fields are not needed, as the only content
+// type offered is dnstap.
+func readControl(r io.Reader) (uint32, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if binary.BigEndian.Uint32(header[:4]) != 0 {
+		return 0, errors.New("expected a control frame")
+	}
+
+	size := binary.BigEndian.Uint32(header[4:])
+	if size < 4 || size > maxControlFrameSize {
+		return 0, fmt.Errorf("invalid control frame size %d", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(frame), nil
+}
+
+func expectControl(r io.Reader, controlType uint32) error {
+	got, err := readControl(r)
+	if err != nil {
+		return err
+	}
+	if got != controlType {
+		return fmt.Errorf("expected control frame %d, got %d", controlType, got)
+	}
+	return nil
+}
+
+func writeFrame(w io.Writer, data []byte) error {
+	if _, err := w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(data)))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,120 @@
+package dnstap
+
+import (
+	"net/netip"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MessageType is the dnstap Message.Type of a message.
+type MessageType uint64
+
+const (
+	ClientQuery       MessageType = 5
+	ClientResponse    MessageType = 6
+	ForwarderQuery    MessageType = 7
+	ForwarderResponse MessageType = 8
+)
+
+// SocketProtocol is the transport a message was sent over.
+type SocketProtocol uint64
+
+const (
+	UDP SocketProtocol = 1
+	TCP SocketProtocol = 2
+	DoT SocketProtocol = 3
+	DoH SocketProtocol = 4
+	DoQ SocketProtocol = 7
+)
+
+const (
+	socketFamilyINET  = 1
+	socketFamilyINET6 = 2
+
+	// Dnstap.Type
+	dnstapTypeMessage = 1
+)
+
+// Message is a single query or response, as defined by the dnstap schema (dnstap.proto).
+type Message struct {
+	Type     MessageType
+	Protocol SocketProtocol
+
+	QueryAddress    netip.AddrPort
+	ResponseAddress netip.AddrPort
+
+	QueryTime    time.Time
+	ResponseTime time.Time
+
+	QueryMessage    []byte
+	ResponseMessage []byte
+}
+
+// marshal encodes m wrapped in a Dnstap message.
+func (m *Message) marshal(identity, version []byte) []byte {
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(m.Type))
+
+	if addr := m.QueryAddress.Addr(); addr.IsValid() {
+		family := uint64(socketFamilyINET6)
+		if addr.Unmap().Is4() {
+			family = socketFamilyINET
+		}
+		msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, family)
+	}
+	if m.Protocol != 0 {
+		msg = protowire.AppendTag(msg, 3, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(m.Protocol))
+	}
+
+	msg = appendAddress(msg, 4, 6, m.QueryAddress)
+	msg = appendAddress(msg, 5, 7, m.ResponseAddress)
+	msg = appendTime(msg, 8, 9, m.QueryTime)
+	if len(m.QueryMessage) > 0 {
+		msg = protowire.AppendTag(msg, 10, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, m.QueryMessage)
+	}
+	msg = appendTime(msg, 12, 13, m.ResponseTime)
+	if len(m.ResponseMessage) > 0 {
+		msg = protowire.AppendTag(msg, 14, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, m.ResponseMessage)
+	}
+
+	var frame []byte
+	if len(identity) > 0 {
+		frame = protowire.AppendTag(frame, 1, protowire.BytesType)
+		frame = protowire.AppendBytes(frame, identity)
+	}
+	if len(version) > 0 {
+		frame = protowire.AppendTag(frame, 2, protowire.BytesType)
+		frame = protowire.AppendBytes(frame, version)
+	}
+	frame = protowire.AppendTag(frame, 14, protowire.BytesType)
+	frame = protowire.AppendBytes(frame, msg)
+	frame = protowire.AppendTag(frame, 15, protowire.VarintType)
+	frame = protowire.AppendVarint(frame, dnstapTypeMessage)
+	return frame
+}
+
+func appendAddress(b []byte, addressField, portField protowire.Number, addr netip.AddrPort) []byte {
+	if !addr.Addr().IsValid() {
+		return b
+	}
+	b = protowire.AppendTag(b, addressField, protowire.BytesType)
+	b = protowire.AppendBytes(b, addr.Addr().Unmap().AsSlice())
+	b = protowire.AppendTag(b, portField, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(addr.Port()))
+}
+
+func appendTime(b []byte, secondsField, nanosecondsField protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	b = protowire.AppendTag(b, secondsField, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t.Unix()))
+	b = protowire.AppendTag(b, nanosecondsField, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, uint32(t.Nanosecond()))
+}
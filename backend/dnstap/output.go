@@ -0,0 +1,252 @@
+package dnstap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"goaway/backend/logging"
+	"goaway/backend/settings"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var log = logging.GetLogger()
+
+const (
+	NetworkUnix = "unix"
+	NetworkTCP  = "tcp"
+	NetworkFile = "file"
+
+	queueSize     = 10000
+	flushInterval = time.Second
+	ioTimeout     = 5 * time.Second
+	maxBackoff    = 30 * time.Second
+)
+
+// Output sends dnstap messages to a Frame Streams receiver, or writes them to a file. Messages are queued
+// and dropped when the receiver can't keep up, so that it never holds up a query.
+type Output struct {
+	network  string
+	address  string
+	identity []byte
+	version  []byte
+
+	queue   chan Message
+	dropped atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Validate reports whether config holds a usable dnstap output.
+func Validate(config settings.DnstapConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	switch config.Network {
+	case NetworkUnix, NetworkTCP, NetworkFile:
+	default:
+		return fmt.Errorf("unknown dnstap network '%s', expected '%s', '%s' or '%s'", config.Network, NetworkUnix, NetworkTCP, NetworkFile)
+	}
+	if config.Address == "" {
+		return errors.New("dnstap address is required")
+	}
+	return nil
+}
+
+// NewOutput starts sending messages to the receiver in config. Version identifies the software sending them.
+func NewOutput(config settings.DnstapConfig, version string) (*Output, error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+
+	identity := config.Identity
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+
+	o := &Output{
+		network:  config.Network,
+		address:  config.Address,
+		identity: []byte(identity),
+		version:  []byte(version),
+		queue:    make(chan Message, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go o.run()
+	return o, nil
+}
+
+// Send queues m without blocking, dropping it when the queue is full.
+func (o *Output) Send(m Message) {
+	select {
+	case o.queue <- m:
+	default:
+		if o.dropped.Add(1) == 1 {
+			log.Warning("dnstap output %s can't keep up, dropping messages", o.address)
+		}
+	}
+}
+
+// Dropped returns the number of messages dropped so far.
+func (o *Output) Dropped() uint64 {
+	return o.dropped.Load()
+}
+
+// Close writes the queued messages and ends the stream.
+func (o *Output) Close() {
+	o.stopOnce.Do(func() {
+		close(o.stop)
+	})
+	<-o.done
+}
+
+func (o *Output) run() {
+	defer close(o.done)
+
+	backoff := time.Second
+	for {
+		conn, err := o.open()
+		if err == nil {
+			backoff = time.Second
+			target := o.address
+			if file, ok := conn.(*os.File); ok {
+				target = file.Name()
+			}
+			log.Info("Sending dnstap messages to %s %s", o.network, target)
+			stopped, streamErr := o.stream(conn)
+			_ = conn.Close()
+			if stopped {
+				if streamErr != nil {
+					log.Debug("dnstap output %s did not end cleanly: %v", o.address, streamErr)
+				}
+				return
+			}
+			err = streamErr
+		}
+
+		log.Warning("dnstap output %s failed, retrying in %s: %v", o.address, backoff, err)
+		select {
+		case <-o.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// open connects to the receiver and completes the Frame Streams handshake. Files are unidirectional
+// streams, which only start with a START frame. As a file holds a single stream, every stream is written
+// to a new file named after the time it started, so that earlier messages are never overwritten.
+func (o *Output) open() (io.ReadWriteCloser, error) {
+	if o.network == NetworkFile {
+		file, err := os.OpenFile(timestampedPath(o.address, time.Now()), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeControl(file, controlStart); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return file, nil
+	}
+
+	conn, err := net.DialTimeout(o.network, o.address, ioTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(ioTimeout))
+	err = writeControl(conn, controlReady)
+	if err == nil {
+		err = expectControl(conn, controlAccept)
+	}
+	if err == nil {
+		err = writeControl(conn, controlStart)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("frame streams handshake failed: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// timestampedPath inserts t before the extension of path, e.g. dnstap-20261019T101500.000.fstrm for dnstap.fstrm.
+func timestampedPath(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + t.Format("20060102T150405.000") + ext
+}
+
+// stream writes queued messages to conn until the output is closed, which is reported by stopped.
+func (o *Output) stream(conn io.ReadWriteCloser) (stopped bool, err error) {
+	// Writes are bounded by a deadline, so that a stalled receiver can't keep the output from being closed
+	w := bufio.NewWriter(conn)
+	extendDeadline := func() {
+		if c, ok := conn.(net.Conn); ok {
+			_ = c.SetWriteDeadline(time.Now().Add(ioTimeout))
+		}
+	}
+	flush := func() error {
+		extendDeadline()
+		return w.Flush()
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m := <-o.queue:
+			if w.Available() < w.Size()/2 {
+				extendDeadline()
+			}
+			if err := writeFrame(w, m.marshal(o.identity, o.version)); err != nil {
+				return false, err
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return false, err
+			}
+		case <-o.stop:
+			return true, o.finish(conn, w, flush)
+		}
+	}
+}
+
+// finish writes the messages still queued and ends the stream with a STOP frame, waiting for the receiver
+// to acknowledge it.
+func (o *Output) finish(conn io.ReadWriteCloser, w *bufio.Writer, flush func() error) error {
+	for len(o.queue) > 0 {
+		m := <-o.queue
+		if err := writeFrame(w, m.marshal(o.identity, o.version)); err != nil {
+			return err
+		}
+		if w.Buffered() > w.Size()/2 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeControl(w, controlStop); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if c, ok := conn.(net.Conn); ok {
+		_ = c.SetReadDeadline(time.Now().Add(ioTimeout))
+		return expectControl(c, controlFinish)
+	}
+	return nil
+}
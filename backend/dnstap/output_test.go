@@ -0,0 +1,243 @@
+package dnstap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"goaway/backend/settings"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var testMessage = Message{
+	Type:            ClientQuery,
+	Protocol:        UDP,
+	QueryAddress:    netip.MustParseAddrPort("192.168.1.10:53000"),
+	ResponseAddress: netip.MustParseAddrPort("192.168.1.1:53"),
+	QueryTime:       time.Unix(1700000000, 500),
+	QueryMessage:    []byte{0x12, 0x34, 0x01, 0x00},
+}
+
+// fields returns the last value of each field in a protobuf message, which is all the tests need.
+func fields(t *testing.T, b []byte) map[protowire.Number][]byte {
+	t.Helper()
+	values := map[protowire.Number][]byte{}
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(number, typ, b)
+		if m < 0 {
+			t.Fatalf("invalid field %d: %v", number, protowire.ParseError(m))
+		}
+		if typ == protowire.BytesType {
+			values[number], _ = protowire.ConsumeBytes(b)
+		} else {
+			values[number] = b[:m]
+		}
+		b = b[m:]
+	}
+	return values
+}
+
+func varint(t *testing.T, b []byte) uint64 {
+	t.Helper()
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		t.Fatalf("invalid varint: %v", protowire.ParseError(n))
+	}
+	return v
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, binary.BigEndian.Uint32(size[:]))
+	_, err := io.ReadFull(r, frame)
+	return frame, err
+}
+
+func checkMessage(t *testing.T, frame []byte) {
+	t.Helper()
+	dnstap := fields(t, frame)
+	if got := string(dnstap[1]); got != "ns1" {
+		t.Errorf("identity %q, want ns1", got)
+	}
+	if got := varint(t, dnstap[15]); got != dnstapTypeMessage {
+		t.Errorf("dnstap type %d, want %d", got, dnstapTypeMessage)
+	}
+
+	message := fields(t, dnstap[14])
+	if got := varint(t, message[1]); got != uint64(ClientQuery) {
+		t.Errorf("message type %d, want %d", got, ClientQuery)
+	}
+	if got := varint(t, message[2]); got != socketFamilyINET {
+		t.Errorf("socket family %d, want %d", got, socketFamilyINET)
+	}
+	if got, _ := netip.AddrFromSlice(message[4]); got != testMessage.QueryAddress.Addr() {
+		t.Errorf("query address %s, want %s", got, testMessage.QueryAddress.Addr())
+	}
+	if got := varint(t, message[7]); got != 53 {
+		t.Errorf("response port %d, want 53", got)
+	}
+	if got := varint(t, message[8]); got != 1700000000 {
+		t.Errorf("query time %d, want 1700000000", got)
+	}
+	if !bytes.Equal(message[10], testMessage.QueryMessage) {
+		t.Errorf("query message %x, want %x", message[10], testMessage.QueryMessage)
+	}
+	if _, ok := message[14]; ok {
+		t.Error("unexpected response message")
+	}
+}
+
+func TestTimestampedPath(t *testing.T) {
+	at := time.Date(2026, 10, 19, 10, 15, 0, 123e6, time.UTC)
+	tests := map[string]string{
+		"/var/log/dnstap.fstrm": "/var/log/dnstap-20261019T101500.123.fstrm",
+		"/var/log/dnstap":       "/var/log/dnstap-20261019T101500.123",
+	}
+	for path, want := range tests {
+		if got := timestampedPath(path, at); got != want {
+			t.Errorf("timestampedPath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	config := settings.DnstapConfig{Enabled: true, Network: NetworkFile, Address: filepath.Join(dir, "dnstap.fstrm"), Identity: "ns1"}
+
+	// Restarting the output starts a new file and keeps the messages written before
+	for range 2 {
+		output, err := NewOutput(config, "goaway")
+		if err != nil {
+			t.Fatalf("NewOutput failed: %v", err)
+		}
+		output.Send(testMessage)
+		output.Close()
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "dnstap-*.fstrm"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("got files %v, %v, want 2", files, err)
+	}
+	for _, file := range files {
+		checkFile(t, file)
+	}
+}
+
+func checkFile(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	r := bytes.NewReader(data)
+	if err := expectControl(r, controlStart); err != nil {
+		t.Fatal(err)
+	}
+	frame, err := readFrame(r)
+	if err != nil {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	checkMessage(t, frame)
+	if err := expectControl(r, controlStop); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutputSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnstap.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	// The receiver hands over the data frame once the stream ended cleanly
+	received := make(chan []byte, 1)
+	failed := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			failed <- err
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		err = expectControl(conn, controlReady)
+		if err == nil {
+			err = writeControl(conn, controlAccept)
+		}
+		if err == nil {
+			err = expectControl(conn, controlStart)
+		}
+		var frame []byte
+		if err == nil {
+			frame, err = readFrame(conn)
+		}
+		if err == nil {
+			err = expectControl(conn, controlStop)
+		}
+		if err == nil {
+			err = writeControl(conn, controlFinish)
+		}
+		if err != nil {
+			failed <- err
+			return
+		}
+		received <- frame
+	}()
+
+	output, err := NewOutput(settings.DnstapConfig{Enabled: true, Network: NetworkUnix, Address: path, Identity: "ns1"}, "goaway")
+	if err != nil {
+		t.Fatalf("NewOutput failed: %v", err)
+	}
+	output.Send(testMessage)
+	output.Close()
+
+	select {
+	case frame := <-received:
+		checkMessage(t, frame)
+	case err := <-failed:
+		t.Fatalf("receiver failed: %v", err)
+	}
+	if dropped := output.Dropped(); dropped != 0 {
+		t.Errorf("dropped %d messages", dropped)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config  settings.DnstapConfig
+		wantErr bool
+	}{
+		{settings.DnstapConfig{Enabled: false, Network: "udp"}, false},
+		{settings.DnstapConfig{Enabled: true, Network: NetworkTCP, Address: "127.0.0.1:6000"}, false},
+		{settings.DnstapConfig{Enabled: true, Network: "udp", Address: "127.0.0.1:6000"}, true},
+		{settings.DnstapConfig{Enabled: true, Network: NetworkUnix}, true},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, want error %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
@@ -23,11 +23,12 @@ func NewBackgroundJobs(registry *services.ServiceRegistry) *BackgroundJobs {
 
 func (b *BackgroundJobs) Start(readyChan <-chan struct{}) {
 	b.startHostnameCachePopulation()
-	b.cleanVendorResponseCache(readyChan)
 	b.startARPProcessing(readyChan)
+	b.startLeaseWatcher(readyChan)
 	b.startScheduledUpdates(readyChan)
 	b.startCacheCleanup(readyChan)
 	b.startPrefetcher(readyChan)
+	b.startCertificateWatcher(readyChan)
 }
 
 func (b *BackgroundJobs) startHostnameCachePopulation() {
@@ -44,11 +45,12 @@ func (b *BackgroundJobs) startARPProcessing(readyChan <-chan struct{}) {
 	}()
 }
 
-func (b *BackgroundJobs) cleanVendorResponseCache(readyChan <-chan struct{}) {
+func (b *BackgroundJobs) startLeaseWatcher(readyChan <-chan struct{}) {
 	go func() {
 		<-readyChan
-		log.Debug("Starting vendor response table processing...")
-		arp.CleanVendorResponseCache()
+		log.Debug("Watching DHCP lease files...")
+		dnsServer := b.registry.Context.DNSServer
+		dnsServer.LeaseService.Watch(b.ctx, dnsServer.ApplyLeases)
 	}()
 }
 
@@ -77,3 +79,11 @@ func (b *BackgroundJobs) startPrefetcher(readyChan <-chan struct{}) {
 		b.registry.PrefetchService.Run(b.ctx)
 	}()
 }
+
+func (b *BackgroundJobs) startCertificateWatcher(readyChan <-chan struct{}) {
+	go func() {
+		<-readyChan
+		log.Debug("Watching TLS certificate files...")
+		b.registry.Context.Certificates.Watch(b.ctx)
+	}()
+}
@@ -88,6 +88,11 @@ func (m *Manager) shutdown() {
 		log.Info("Stopped DNS-over-TLS server")
 	}
 
+	if m.services.DoQServer != nil {
+		m.services.DoQServer.Shutdown(ctx)
+		log.Info("Stopped DNS-over-QUIC server")
+	}
+
 	if m.services.DoHServer != nil {
 		if err := m.services.DoHServer.Shutdown(ctx); err != nil && err != context.DeadlineExceeded {
 			log.Error("Error stopping DoH server: %v", err)
@@ -95,6 +100,16 @@ func (m *Manager) shutdown() {
 		log.Info("Stopped DNS-over-HTTPS server")
 	}
 
+	if m.services.DoH3Server != nil {
+		if err := m.services.DoH3Server.Shutdown(ctx); err != nil && err != context.DeadlineExceeded {
+			log.Error("Error stopping DoH3 server: %v", err)
+		}
+		log.Info("Stopped DNS-over-HTTP/3 server")
+	}
+
+	m.services.Context.DNSServer.CloseDnstap()
+	m.services.Context.Syslog.Close()
+
 	// Wait for all goroutines to finish with timeout
 	done := make(chan struct{})
 	go func() {
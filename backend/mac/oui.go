@@ -0,0 +1,107 @@
+package mac
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run oui_generate.go
+
+// The IEEE registries, reduced to "Registry,Assignment,Organization Name" by oui_generate.go
+//
+//go:embed oui.csv.gz
+var embeddedRegistry []byte
+
+// Prefix lengths in bits of the IEEE assignment blocks, longest first
+var prefixBits = []uint{36, 28, 24}
+
+// Registry maps IEEE assigned MAC prefixes to the organization they are assigned to.
+type Registry struct {
+	prefixes map[uint]map[uint64]string
+	size     int
+}
+
+func loadEmbeddedRegistry() (*Registry, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(embeddedRegistry))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	return ParseRegistry(reader)
+}
+
+// ParseRegistry reads registries in the CSV format published by the IEEE
+// (https://standards-oui.ieee.org/oui/oui.csv, mam.csv and oui36.csv). Several registries may be
+// concatenated into one file.
+func ParseRegistry(r io.Reader) (*Registry, error) {
+	registry := &Registry{prefixes: map[uint]map[uint64]string{}}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid vendor registry: %w", err)
+		}
+		if len(record) < 3 || record[0] == "Registry" {
+			continue
+		}
+
+		assignment := strings.TrimSpace(record[1])
+		prefix, err := strconv.ParseUint(assignment, 16, 64)
+		if err != nil {
+			continue
+		}
+
+		bits := uint(len(assignment) * 4)
+		if registry.prefixes[bits] == nil {
+			registry.prefixes[bits] = map[uint64]string{}
+		}
+		registry.prefixes[bits][prefix] = strings.TrimSpace(record[2])
+		registry.size++
+	}
+
+	if registry.size == 0 {
+		return nil, fmt.Errorf("vendor registry has no assignments")
+	}
+	return registry, nil
+}
+
+// Lookup returns the organization the longest matching prefix of mac is assigned to.
+func (r *Registry) Lookup(mac string) (string, bool) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return "", false
+	}
+
+	var value uint64
+	for _, b := range hw {
+		value = value<<8 | uint64(b)
+	}
+
+	for _, bits := range prefixBits {
+		if vendor, found := r.prefixes[bits][value>>(48-bits)]; found {
+			return vendor, true
+		}
+	}
+	return "", false
+}
+
+func (r *Registry) Size() int {
+	return r.size
+}
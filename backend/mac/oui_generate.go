@@ -0,0 +1,125 @@
+//go:build ignore
+
+// Downloads the IEEE MAC address registries and writes the reduced copy embedded in the binary.
+// Copies of oui.csv, mam.csv and oui36.csv downloaded beforehand can be passed as arguments instead:
+//
+//	go run oui_generate.go oui.csv mam.csv oui36.csv
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var registries = []string{
+	"https://standards-oui.ieee.org/oui/oui.csv",
+	"https://standards-oui.ieee.org/oui28/mam.csv",
+	"https://standards-oui.ieee.org/oui36/oui36.csv",
+}
+
+func main() {
+	sources := registries
+	if len(os.Args) > 1 {
+		sources = os.Args[1:]
+	}
+
+	if err := generate("oui.csv.gz", sources); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate writes the registries to path, which is only replaced once every registry was read.
+func generate(path string, sources []string) error {
+	file, err := os.CreateTemp(".", path+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	compressed, err := gzip.NewWriterLevel(file, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(compressed)
+	if err := writer.Write([]string{"Registry", "Assignment", "Organization Name"}); err != nil {
+		return err
+	}
+
+	for _, source := range sources {
+		if err := copyRegistry(source, writer); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func copyRegistry(source string, writer *csv.Writer) error {
+	body, err := openRegistry(source)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if len(record) < 3 || record[0] == "Registry" {
+			continue
+		}
+		if err := writer.Write(record[:3]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openRegistry reads source from the IEEE when it is a URL, or from a local copy otherwise.
+func openRegistry(source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, source, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	// The IEEE rejects requests without a browser-like user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; goaway)")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
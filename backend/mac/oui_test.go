@@ -0,0 +1,96 @@
+package mac
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRegistryLookupPrefersLongestPrefix(t *testing.T) {
+	registry, err := ParseRegistry(strings.NewReader(`Registry,Assignment,Organization Name,Organization Address
+MA-L,70B3D5,IEEE Registration Authority,"445 Hoes Lane Piscataway NJ US 08554"
+MA-M,70B3D51,"Example Medium, Inc.",Somewhere
+MA-S,70B3D5123,Example Small,Somewhere
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"70:b3:d5:12:34:56": "Example Small",
+		"70-B3-D5-19-00-00": "Example Medium, Inc.",
+		"70:b3:d5:ff:00:00": "IEEE Registration Authority",
+	}
+	for mac, want := range tests {
+		if got, found := registry.Lookup(mac); !found || got != want {
+			t.Errorf("Lookup(%s) = %q, %v, want %q", mac, got, found, want)
+		}
+	}
+
+	if _, found := registry.Lookup("00:11:22:33:44:55"); found {
+		t.Error("unassigned prefix should not be found")
+	}
+}
+
+func TestEmbeddedRegistry(t *testing.T) {
+	registry, err := loadEmbeddedRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The MA-L registry alone holds well over 30000 assignments
+	if size := registry.Size(); size < 30000 {
+		t.Errorf("Size() = %d, want at least 30000", size)
+	}
+
+	tests := map[string]string{
+		"b8:27:eb:12:34:56": "Raspberry Pi Foundation",
+		"00:0c:29:00:00:01": "VMware, Inc.",
+		"00:03:93:aa:bb:cc": "Apple, Inc.",
+	}
+	for mac, want := range tests {
+		if got, found := registry.Lookup(mac); !found || got != want {
+			t.Errorf("Lookup(%s) = %q, %v, want %q", mac, got, found, want)
+		}
+	}
+}
+
+// The 28 and 36 bit blocks must resolve to their own organization rather than the owner of the enclosing
+// MA-L block, which is usually the IEEE Registration Authority.
+func TestEmbeddedRegistrySmallBlocks(t *testing.T) {
+	registry, err := loadEmbeddedRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(embeddedRegistry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"MA-M", "MA-S"} {
+		var record []string
+		for _, candidate := range records {
+			if candidate[0] == name {
+				record = candidate
+				break
+			}
+		}
+		if record == nil {
+			t.Skipf("embedded registry has no %s assignments, regenerate it with go generate ./backend/mac", name)
+		}
+
+		// The assignment followed by zeroes is the first address of the block
+		hex := record[1] + strings.Repeat("0", 12-len(record[1]))
+		mac := fmt.Sprintf("%s:%s:%s:%s:%s:%s", hex[0:2], hex[2:4], hex[4:6], hex[6:8], hex[8:10], hex[10:12])
+		if got, found := registry.Lookup(mac); !found || got != record[2] {
+			t.Errorf("%s Lookup(%s) = %q, %v, want %q", name, mac, got, found, record[2])
+		}
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"goaway/backend/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	FindVendor(mac string) (string, error)
+	FindDevice(mac string) (*database.MacAddress, error)
 	SaveMac(clientIP, mac, vendor string) error
 }
 
@@ -35,13 +37,35 @@ func (r *repository) FindVendor(mac string) (string, error) {
 	return query.Vendor, nil
 }
 
+func (r *repository) FindDevice(mac string) (*database.MacAddress, error) {
+	var device database.MacAddress
+	tx := r.db.Limit(1).Find(&device, "mac = ?", mac)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &device, nil
+}
+
+// SaveMac stores the current IP and vendor of a device, keeping its name and bypass setting.
 func (r *repository) SaveMac(clientIP, mac, vendor string) error {
 	entry := database.MacAddress{
 		MAC:    mac,
 		IP:     clientIP,
 		Vendor: vendor,
 	}
-	tx := r.db.Save(&entry)
+
+	updates := []string{"ip", "updated_at"}
+	if vendor != "" {
+		updates = append(updates, "vendor")
+	}
+	tx := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "mac"}},
+		DoUpdates: clause.AssignmentColumns(updates),
+	}).Create(&entry)
 
 	if tx.Error != nil {
 		return fmt.Errorf("unable to save new MAC entry %v", tx.Error)
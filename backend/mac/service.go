@@ -1,24 +1,86 @@
 package mac
 
-import "goaway/backend/logging"
+import (
+	"fmt"
+	"goaway/backend/database"
+	"goaway/backend/logging"
+	"os"
+	"sync"
+)
 
 type Service struct {
 	repository Repository
+
+	mu       sync.RWMutex
+	registry *Registry
 }
 
 var log = logging.GetLogger()
 
 func NewService(repo Repository) *Service {
-	return &Service{repository: repo}
+	registry, err := loadEmbeddedRegistry()
+	if err != nil {
+		log.Warning("Could not load embedded vendor registry, %v", err)
+		registry = &Registry{}
+	}
+
+	return &Service{repository: repo, registry: registry}
 }
 
 func (s *Service) FindVendor(mac string) (string, error) {
 	return s.repository.FindVendor(mac)
 }
 
+// FindDevice returns the stored device for mac, or nil when it has not been seen before.
+func (s *Service) FindDevice(mac string) (*database.MacAddress, error) {
+	return s.repository.FindDevice(mac)
+}
+
 func (s *Service) SaveMac(clientIP, mac, vendor string) {
 	err := s.repository.SaveMac(clientIP, mac, vendor)
 	if err != nil {
 		log.Warning("Could not save MAC address, %v", err)
 	}
 }
+
+// LookupVendor returns the vendor stored for mac, falling back to the vendor registry.
+// Vendors found in the registry are stored together with the client IP.
+func (s *Service) LookupVendor(clientIP, mac string) (string, error) {
+	vendor, err := s.FindVendor(mac)
+	if err == nil && vendor != "" {
+		return vendor, nil
+	}
+
+	s.mu.RLock()
+	vendor, found := s.registry.Lookup(mac)
+	s.mu.RUnlock()
+	if !found {
+		return "", fmt.Errorf("vendor not found for mac %s", mac)
+	}
+
+	s.SaveMac(clientIP, mac, vendor)
+	return vendor, nil
+}
+
+// LoadRegistryFile replaces the vendor registry with one read from a local file in the IEEE CSV format.
+func (s *Service) LoadRegistryFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open vendor registry: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	registry, err := ParseRegistry(file)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.registry = registry
+	s.mu.Unlock()
+
+	log.Info("Loaded %d vendor assignments from %s", registry.Size(), path)
+	return registry.Size(), nil
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "goaway"
+
+// Results of a list update
+const (
+	ListUpdated   = "updated"
+	ListUnchanged = "unchanged"
+	ListSkipped   = "skipped"
+	ListFailed    = "failed"
+)
+
+// Reasons for records leaving the cache
+const (
+	CacheExpired = "expired"
+	CacheRemoved = "removed"
+)
+
+var (
+	queries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_queries_total",
+		Help:      "Queries answered, by protocol, query type and response code.",
+	}, []string{"protocol", "type", "rcode", "blocked", "cached"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dns_query_duration_seconds",
+		Help:      "Time taken to answer a query.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"protocol", "cached"})
+
+	upstreamDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by an upstream server to answer a query.",
+		Buckets:   []float64{.0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"upstream"})
+
+	upstreamErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Queries an upstream server failed to answer.",
+	}, []string{"upstream"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_cache_lookups_total",
+		Help:      "Cache lookups, by whether a valid record was found.",
+	}, []string{"result"})
+
+	cacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_cache_evictions_total",
+		Help:      "Records removed from the cache, by reason.",
+	}, []string{"reason"})
+
+	listUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "list_updates_total",
+		Help:      "Blocklist updates, by list and result.",
+	}, []string{"list", "result"})
+)
+
+// NewRegistry returns a registry with the metrics of this package, the Go runtime and the process, along with
+// collectors reading the current state of the server.
+func NewRegistry(state ...prometheus.Collector) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		queries, queryDuration,
+		upstreamDuration, upstreamErrors,
+		cacheLookups, cacheEvictions,
+		listUpdates,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	registry.MustRegister(state...)
+	return registry
+}
+
+// ObserveQuery records an answered query.
+func ObserveQuery(protocol, queryType, rcode string, blocked, cached bool, duration time.Duration) {
+	queries.WithLabelValues(protocol, queryType, rcode, strconv.FormatBool(blocked), strconv.FormatBool(cached)).Inc()
+	queryDuration.WithLabelValues(protocol, strconv.FormatBool(cached)).Observe(duration.Seconds())
+}
+
+// ObserveUpstream records a query sent to upstream, which failed when err is not nil.
+func ObserveUpstream(upstream string, duration time.Duration, err error) {
+	if err != nil {
+		upstreamErrors.WithLabelValues(upstream).Inc()
+		return
+	}
+	upstreamDuration.WithLabelValues(upstream).Observe(duration.Seconds())
+}
+
+// ObserveCacheLookup records whether a valid record was found in the cache.
+func ObserveCacheLookup(hit bool) {
+	if hit {
+		cacheLookups.WithLabelValues("hit").Inc()
+	} else {
+		cacheLookups.WithLabelValues("miss").Inc()
+	}
+}
+
+// ObserveCacheEviction records a record leaving the cache for reason.
+func ObserveCacheEviction(reason string) {
+	cacheEvictions.WithLabelValues(reason).Inc()
+}
+
+// ObserveListUpdate records the result of updating list.
+func ObserveListUpdate(list, result string) {
+	listUpdates.WithLabelValues(list, result).Inc()
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func find(t *testing.T, families []*dto.MetricFamily, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if want, ok := labels[label.GetName()]; ok && want != label.GetValue() {
+					continue metrics
+				}
+			}
+			return metric
+		}
+	}
+	t.Fatalf("metric %s %v not found", name, labels)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	ObserveQuery("UDP", "A", "NOERROR", false, true, 2*time.Millisecond)
+	ObserveQuery("UDP", "A", "NOERROR", false, true, 3*time.Millisecond)
+	ObserveUpstream("1.1.1.1:53", 20*time.Millisecond, nil)
+	ObserveUpstream("1.1.1.1:53", 0, errors.New("timeout"))
+	ObserveListUpdate("StevenBlack", ListUpdated)
+
+	families, err := NewRegistry().Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+
+	queries := find(t, families, "goaway_dns_queries_total", map[string]string{"protocol": "UDP", "type": "A", "cached": "true"})
+	if got := queries.GetCounter().GetValue(); got != 2 {
+		t.Errorf("got %v queries, want 2", got)
+	}
+	duration := find(t, families, "goaway_dns_query_duration_seconds", map[string]string{"protocol": "UDP"})
+	if got := duration.GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("got %d query durations, want 2", got)
+	}
+	upstream := find(t, families, "goaway_upstream_request_duration_seconds", map[string]string{"upstream": "1.1.1.1:53"})
+	if got := upstream.GetHistogram().GetSampleCount(); got != 1 {
+		t.Errorf("got %d upstream durations, want 1", got)
+	}
+	upstreamErrors := find(t, families, "goaway_upstream_errors_total", map[string]string{"upstream": "1.1.1.1:53"})
+	if got := upstreamErrors.GetCounter().GetValue(); got != 1 {
+		t.Errorf("got %v upstream errors, want 1", got)
+	}
+	find(t, families, "goaway_list_updates_total", map[string]string{"list": "StevenBlack", "result": ListUpdated})
+	find(t, families, "go_goroutines", nil)
+
+	// Registries are created again when the application restarts
+	if _, err := NewRegistry().Gather(); err != nil {
+		t.Fatalf("second registry failed: %v", err)
+	}
+}
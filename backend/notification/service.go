@@ -3,10 +3,14 @@ package notification
 import (
 	"goaway/backend/database"
 	"goaway/backend/logging"
+	"goaway/backend/syslog"
 )
 
 type Service struct {
 	repository Repository
+
+	// Forwards notifications to a remote syslog server
+	Syslog *syslog.Forwarder
 }
 
 type Severity string
@@ -42,6 +46,7 @@ func (s *Service) SendNotification(severity Severity, category Category, text st
 		Text:     text,
 		Read:     false,
 	}
+	s.Syslog.Notification(string(severity), string(category), text)
 
 	err := s.repository.CreateNotification(notification)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"goaway/backend/database"
 	"goaway/backend/dns/server"
 	"goaway/backend/logging"
+	"goaway/backend/metrics"
 	"time"
 
 	"codeberg.org/miekg/dns"
@@ -95,6 +96,7 @@ func (s *Service) handleExpiredKeys(expiredKeys []interface{}) {
 		if value, exists := s.DNS.DomainCache.Load(key); exists {
 			if cachedDomain, ok := value.(server.CachedRecord); ok {
 				s.DNS.DomainCache.Delete(key)
+				metrics.ObserveCacheEviction(metrics.CacheExpired)
 				s.handleExpiredEntry(cachedDomain)
 			}
 		}
@@ -0,0 +1,141 @@
+package request
+
+import (
+	"fmt"
+	"goaway/backend/api/models"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sortColumns are the request log columns queries can be sorted by. Only these are ever put in a query.
+var sortColumns = map[string]bool{
+	"timestamp":           true,
+	"domain":              true,
+	"client_ip":           true,
+	"ip":                  true,
+	"status":              true,
+	"protocol":            true,
+	"response_time_ns":    true,
+	"query_type":          true,
+	"blocked":             true,
+	"cached":              true,
+	"response_size_bytes": true,
+}
+
+// applyQueryFilter narrows query, on request_logs, down to the queries matching f.
+func applyQueryFilter(query *gorm.DB, f models.QueryFilter) *gorm.DB {
+	if f.Domain != "" {
+		domain := strings.ToLower(f.Domain)
+		if f.ExactDomain {
+			domain = strings.TrimSuffix(domain, ".")
+			query = query.Where("request_logs.domain IN ?", []string{domain, domain + "."})
+		} else {
+			query = query.Where("request_logs.domain LIKE ?", "%"+domain+"%")
+		}
+	}
+
+	if f.Client != "" {
+		query = query.Where("(request_logs.client_ip LIKE ? OR request_logs.client_name LIKE ?)",
+			"%"+f.Client+"%", "%"+f.Client+"%")
+	}
+
+	if !f.From.IsZero() {
+		query = query.Where("request_logs.timestamp >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("request_logs.timestamp < ?", f.To)
+	}
+
+	if len(f.Statuses) > 0 {
+		query = query.Where("UPPER(request_logs.status) IN ?", upper(f.Statuses))
+	}
+	if len(f.QueryTypes) > 0 {
+		query = query.Where("UPPER(request_logs.query_type) IN ?", upper(f.QueryTypes))
+	}
+	if len(f.Protocols) > 0 {
+		query = query.Where("UPPER(request_logs.protocol) IN ?", upper(f.Protocols))
+	}
+
+	if f.Blocked != nil {
+		query = query.Where("request_logs.blocked = ?", *f.Blocked)
+	}
+	if f.Cached != nil {
+		query = query.Where("request_logs.cached = ?", *f.Cached)
+	}
+
+	if f.MinResponseTime > 0 {
+		query = query.Where("request_logs.response_time_ns >= ?", f.MinResponseTime.Nanoseconds())
+	}
+	if f.MaxResponseTime > 0 {
+		query = query.Where("request_logs.response_time_ns <= ?", f.MaxResponseTime.Nanoseconds())
+	}
+
+	if f.ResolvedIP != "" {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM request_log_ips WHERE request_log_ips.request_log_id = request_logs.id AND request_log_ips.ip = ?)",
+			f.ResolvedIP,
+		)
+	}
+
+	return query
+}
+
+// applyQueryOrder sorts query by q.Column, continuing after q.Cursor when set. Ties are broken by ID, so
+// that pages are stable while new queries are logged.
+func applyQueryOrder(query *gorm.DB, q models.QueryParams) (*gorm.DB, error) {
+	if !sortColumns[q.Column] {
+		return nil, fmt.Errorf("can't sort by '%s'", q.Column)
+	}
+
+	var desc bool
+	switch strings.ToUpper(q.Direction) {
+	case "ASC":
+	case "DESC", "":
+		desc = true
+	default:
+		return nil, fmt.Errorf("invalid sort direction '%s'", q.Direction)
+	}
+
+	id := clause.Column{Table: "request_logs", Name: "id"}
+	if q.Column == "ip" {
+		if q.Cursor > 0 {
+			return nil, fmt.Errorf("cursors can't be used when sorting by resolved IP")
+		}
+		direction := "ASC"
+		if desc {
+			direction = "DESC"
+		}
+		return query.
+			Joins("LEFT JOIN request_log_ips ri ON request_logs.id = ri.request_log_id").
+			Group("request_logs.id").
+			Order("MAX(ri.ip) " + direction).
+			Order(clause.OrderByColumn{Column: id, Desc: desc}), nil
+	}
+
+	column := clause.Column{Table: "request_logs", Name: q.Column}
+	if q.Cursor > 0 {
+		// Compared with the row the cursor points at, so that values never have to be converted
+		comparison := ">"
+		if desc {
+			comparison = "<"
+		}
+		query = query.Where(
+			"(?, ?) "+comparison+" (SELECT ?, id FROM request_logs WHERE id = ?)",
+			column, id, clause.Column{Name: q.Column}, q.Cursor,
+		)
+	}
+
+	return query.
+		Order(clause.OrderByColumn{Column: column, Desc: desc}).
+		Order(clause.OrderByColumn{Column: id, Desc: desc}), nil
+}
+
+func upper(values []string) []string {
+	upper := make([]string, len(values))
+	for i, value := range values {
+		upper[i] = strings.ToUpper(strings.TrimSpace(value))
+	}
+	return upper
+}
@@ -7,13 +7,19 @@ import (
 	"goaway/backend/api/models"
 	"goaway/backend/database"
 	model "goaway/backend/dns/server/models"
+	"net"
 	"net/netip"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// clientIdentity groups request logs per client. Clients are identified by their client ID, then by
+// the MAC of the device, falling back to the client IP when neither is known.
+const clientIdentity = "CASE WHEN client_id != '' THEN 'id:' || client_id WHEN client_mac != '' THEN client_mac ELSE client_ip END"
+
 type Repository interface {
 	SaveRequestLog(entries []model.RequestLogEntry) error
 
@@ -30,7 +36,7 @@ type Repository interface {
 	GetTopBlockedDomains(blockedRequests int) ([]map[string]any, error)
 	GetTopQueriedDomains() ([]map[string]any, error)
 	GetTopClients() ([]map[string]any, error)
-	CountQueries(search string) (int, error)
+	CountQueries(filter models.QueryFilter) (int, error)
 
 	UpdateClientName(ip string, name string) error
 	UpdateClientBypass(ip string, bypass bool) error
@@ -67,6 +73,8 @@ func (r *repository) SaveRequestLog(entries []model.RequestLogEntry) error {
 				ResponseTimeNs:    entry.ResponseTime.Nanoseconds(),
 				ClientIP:          entry.ClientInfo.IP.String(),
 				ClientName:        entry.ClientInfo.Name,
+				ClientMAC:         deviceMAC(entry.ClientInfo.Mac),
+				ClientID:          entry.ClientInfo.ID,
 				Status:            entry.Status,
 				QueryType:         entry.QueryType,
 				ResponseSizeBytes: entry.ResponseSizeBytes,
@@ -107,7 +115,7 @@ func (r *repository) GetDistinctRequestIP() int {
 	var count int64
 
 	err := r.db.Model(&database.RequestLog{}).
-		Select("COUNT(DISTINCT client_ip)").
+		Select("COUNT(DISTINCT " + clientIdentity + ")").
 		Scan(&count).Error
 	if err != nil {
 		return 0
@@ -217,31 +225,17 @@ func (r *repository) GetUniqueQueryTypes() ([]models.QueryTypeCount, error) {
 
 func (r *repository) FetchQueries(q models.QueryParams) ([]model.RequestLogEntry, error) {
 	var logs []database.RequestLog
-	query := r.db.Model(&database.RequestLog{})
-
-	if q.Column == "ip" {
-		query = query.Joins("LEFT JOIN request_log_ips ri ON request_logs.id = ri.request_log_id")
-	}
-
-	if q.Search != "" {
-		query = query.Where("request_logs.domain LIKE ?", "%"+q.Search+"%")
-	}
-
-	if q.FilterClient != "" {
-		query = query.Where("request_logs.client_ip LIKE ? OR request_logs.client_name LIKE ?",
-			"%"+q.FilterClient+"%", "%"+q.FilterClient+"%")
-	}
+	query := applyQueryFilter(r.db.Model(&database.RequestLog{}), q.QueryFilter)
 
-	if q.Column == "ip" {
-		query = query.Group("request_logs.id").Order("MAX(ri.ip) " + q.Direction)
-	} else {
-		query = query.Order("request_logs." + q.Column + " " + q.Direction)
+	query, err := applyQueryOrder(query, q)
+	if err != nil {
+		return nil, err
 	}
 
 	if q.PageSize > 0 {
 		query = query.Limit(q.PageSize)
 	}
-	if q.Offset > 0 {
+	if q.Offset > 0 && q.Cursor == 0 {
 		query = query.Offset(q.Offset)
 	}
 
@@ -286,66 +280,129 @@ func (r *repository) FetchQueries(q models.QueryParams) ([]model.RequestLogEntry
 	return results, nil
 }
 
-func (r *repository) FetchClient(ip string) (*model.Client, error) {
-	var row struct {
-		ClientIP   string         `gorm:"column:client_ip"`
-		ClientName string         `gorm:"column:client_name"`
-		Timestamp  time.Time      `gorm:"column:timestamp"`
-		Mac        sql.NullString `gorm:"column:mac"`
-		Vendor     sql.NullString `gorm:"column:vendor"`
-		Bypass     sql.NullBool   `gorm:"column:bypass"`
+type clientRow struct {
+	ClientIP   string         `gorm:"column:client_ip"`
+	ClientID   string         `gorm:"column:client_id"`
+	ClientName string         `gorm:"column:client_name"`
+	Timestamp  time.Time      `gorm:"column:timestamp"`
+	Mac        sql.NullString `gorm:"column:mac"`
+	Vendor     sql.NullString `gorm:"column:vendor"`
+	Name       sql.NullString `gorm:"column:name"`
+	Bypass     sql.NullBool   `gorm:"column:bypass"`
+}
+
+func (row clientRow) client() (model.Client, error) {
+	clientIP, err := netip.ParseAddr(row.ClientIP)
+	if err != nil {
+		return model.Client{}, fmt.Errorf("failed to parse client IP '%s': %v", row.ClientIP, err)
 	}
 
-	query := `
-		SELECT r.client_ip, r.client_name, r.timestamp, m.mac, m.vendor, m.bypass
-		FROM request_logs r
-		LEFT JOIN mac_addresses m ON r.client_ip = m.ip
-		WHERE r.client_ip = ?
-		ORDER BY r.timestamp DESC
-		LIMIT 1
-	`
+	client := model.Client{
+		ID:       row.ClientID,
+		IP:       clientIP,
+		Name:     row.ClientName,
+		LastSeen: row.Timestamp,
+		Mac:      row.Mac.String,
+		Vendor:   row.Vendor.String,
+		Bypass:   row.Bypass.Bool,
+	}
+	if row.Name.String != "" {
+		client.Name = row.Name.String
+	}
+	return client, nil
+}
 
-	if err := r.db.Raw(query, ip).Scan(&row).Error; err != nil {
+// FetchClient returns the client with the given IP address or client ID.
+func (r *repository) FetchClient(key string) (*model.Client, error) {
+	var (
+		row   clientRow
+		query string
+		args  []any
+	)
+
+	if isClientID(key) {
+		query = `
+			SELECT r.client_ip, r.client_id, r.client_name, r.timestamp, c.name, c.bypass
+			FROM request_logs r
+			LEFT JOIN client_identifiers c ON c.id = r.client_id
+			WHERE r.client_id = ?
+			ORDER BY r.timestamp DESC
+			LIMIT 1
+		`
+		args = []any{key}
+	} else if mac := r.findDeviceMAC(key); mac != "" {
+		query = `
+			SELECT r.client_ip, r.client_id, r.client_name, r.timestamp, m.mac, m.vendor, m.name, m.bypass
+			FROM request_logs r
+			LEFT JOIN mac_addresses m ON m.mac = ?
+			WHERE r.client_id = '' AND (r.client_mac = ? OR (r.client_mac = '' AND r.client_ip = ?))
+			ORDER BY r.timestamp DESC
+			LIMIT 1
+		`
+		args = []any{mac, mac, key}
+	} else {
+		query = `
+			SELECT r.client_ip, r.client_id, r.client_name, r.timestamp, m.mac, m.vendor, m.name, m.bypass
+			FROM request_logs r
+			LEFT JOIN mac_addresses m ON r.client_ip = m.ip
+			WHERE r.client_id = '' AND r.client_ip = ?
+			ORDER BY r.timestamp DESC
+			LIMIT 1
+		`
+		args = []any{key}
+	}
+
+	if err := r.db.Raw(query, args...).Scan(&row).Error; err != nil {
 		return nil, err
 	}
 
 	if row.ClientIP == "" {
-		return nil, fmt.Errorf("client with ip '%s' was not found", ip)
+		if isClientID(key) {
+			return r.fetchClientIdentifier(key)
+		}
+		return nil, fmt.Errorf("client '%s' was not found", key)
 	}
 
-	clientIP, err := netip.ParseAddr(row.ClientIP)
+	client, err := row.client()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse client IP '%s': %v", row.ClientIP, err)
+		return nil, err
 	}
-	client := &model.Client{
-		IP:       clientIP,
-		Name:     row.ClientName,
-		LastSeen: row.Timestamp,
-		Mac:      row.Mac.String,
-		Vendor:   row.Vendor.String,
-		Bypass:   row.Bypass.Bool,
+	return &client, nil
+}
+
+// fetchClientIdentifier returns the name and bypass configured for a client ID that has not sent a query yet.
+func (r *repository) fetchClientIdentifier(id string) (*model.Client, error) {
+	var identifiers []database.ClientIdentifier
+	if err := r.db.Where("id = ?", id).Limit(1).Find(&identifiers).Error; err != nil {
+		return nil, err
+	}
+	if len(identifiers) == 0 {
+		return nil, fmt.Errorf("client '%s' was not found", id)
 	}
 
+	client := &model.Client{ID: id, Name: identifiers[0].Name, Bypass: identifiers[0].Bypass}
+	if client.Name == "" {
+		client.Name = id
+	}
 	return client, nil
 }
 
+// FetchAllClients returns the most recent state of every client, keyed by client ID or IP address.
+// Clients with a known MAC are merged into one device across all addresses it has used.
 func (r *repository) FetchAllClients() (map[string]model.Client, error) {
-	var rows []struct {
-		ClientIP   string         `gorm:"column:client_ip"`
-		ClientName string         `gorm:"column:client_name"`
-		Timestamp  time.Time      `gorm:"column:timestamp"`
-		Mac        sql.NullString `gorm:"column:mac"`
-		Vendor     sql.NullString `gorm:"column:vendor"`
-		Bypass     sql.NullBool   `gorm:"column:bypass"`
-	}
+	var rows []clientRow
 
 	query := `
-		SELECT DISTINCT r.client_ip, r.client_name, r.timestamp, m.mac, m.vendor, m.bypass
+		SELECT r.client_ip, r.client_id, r.client_name, r.timestamp, m.mac, m.vendor,
+			COALESCE(c.name, m.name) as name, COALESCE(c.bypass, m.bypass) as bypass
 		FROM (
-			SELECT client_ip, client_name, timestamp, ROW_NUMBER() OVER (PARTITION BY client_ip ORDER BY timestamp DESC) as rn
+			SELECT client_ip, client_mac, client_id, client_name, timestamp,
+				ROW_NUMBER() OVER (PARTITION BY ` + clientIdentity + ` ORDER BY timestamp DESC) as rn
 			FROM request_logs
 		) r
-		LEFT JOIN mac_addresses m ON r.client_ip = m.ip
+		LEFT JOIN mac_addresses m ON r.client_id = '' AND
+			((r.client_mac != '' AND m.mac = r.client_mac) OR (r.client_mac = '' AND m.ip = r.client_ip))
+		LEFT JOIN client_identifiers c ON r.client_id != '' AND c.id = r.client_id
 		WHERE r.rn = 1
 		ORDER BY r.timestamp DESC
 	`
@@ -355,20 +412,27 @@ func (r *repository) FetchAllClients() (map[string]model.Client, error) {
 	}
 
 	uniqueClients := make(map[string]model.Client, len(rows))
+	seenDevices := make(map[string]bool, len(rows))
 	for _, row := range rows {
-		clientIP, err := netip.ParseAddr(row.ClientIP)
+		client, err := row.client()
 		if err != nil {
-			log.Warning("failed to parse client IP '%s': %v", row.ClientIP, err)
+			log.Warning("%v", err)
+			continue
+		}
+
+		if client.ID != "" {
+			uniqueClients[client.ID] = client
+			continue
+		}
+
+		// Rows are ordered by recency, so the latest address of a device wins
+		if _, exists := uniqueClients[row.ClientIP]; exists || seenDevices[client.Mac] {
 			continue
 		}
-		uniqueClients[row.ClientIP] = model.Client{
-			IP:       clientIP,
-			Name:     row.ClientName,
-			LastSeen: row.Timestamp,
-			Mac:      row.Mac.String,
-			Vendor:   row.Vendor.String,
-			Bypass:   row.Bypass.Bool,
+		if client.Mac != "" {
+			seenDevices[client.Mac] = true
 		}
+		uniqueClients[row.ClientIP] = client
 	}
 
 	return uniqueClients, nil
@@ -376,6 +440,7 @@ func (r *repository) FetchAllClients() (map[string]model.Client, error) {
 
 func (r *repository) GetClientDetailsWithDomains(clientIP string) (ClientRequestDetails, string, map[string]int, error) {
 	var crd ClientRequestDetails
+	forClient := r.clientScope(clientIP)
 	err := r.db.Table("request_logs").
 		Select(`
 			COUNT(*) as total_requests,
@@ -384,7 +449,7 @@ func (r *repository) GetClientDetailsWithDomains(clientIP string) (ClientRequest
 			SUM(CASE WHEN cached THEN 1 ELSE 0 END) as cached_requests,
 			AVG(response_time_ns) / 1e6 as avg_response_time_ms,
 			MAX(timestamp) as last_seen`).
-		Where("client_ip = ?", clientIP).
+		Scopes(forClient).
 		Scan(&crd).Error
 
 	if err != nil {
@@ -398,7 +463,7 @@ func (r *repository) GetClientDetailsWithDomains(clientIP string) (ClientRequest
 
 	err = r.db.Table("request_logs").
 		Select("domain, COUNT(*) as query_count").
-		Where("client_ip = ?", clientIP).
+		Scopes(forClient).
 		Group("domain").
 		Order("query_count DESC").
 		Scan(&rows).Error
@@ -429,7 +494,7 @@ func (r *repository) GetClientHistory(clientIP string) ([]models.DomainHistory,
 
 	err := r.db.Table("request_logs").
 		Select("domain, timestamp").
-		Where("client_ip = ?", clientIP).
+		Scopes(r.clientScope(clientIP)).
 		Order("timestamp DESC").
 		Limit(1000).
 		Scan(&history).Error
@@ -505,15 +570,16 @@ func (r *repository) GetTopClients() ([]map[string]any, error) {
 	}
 
 	var rows []struct {
-		ClientIP     string  `gorm:"column:client_ip"`
+		Client       string  `gorm:"column:client"`
 		ClientName   string  `gorm:"column:client_name"`
 		RequestCount int     `gorm:"column:request_count"`
 		Frequency    float32 `gorm:"column:frequency"`
 	}
 
 	if err := r.db.Table("request_logs").
-		Select("? as frequency, client_ip, client_name, COUNT(*) as request_count", 0).
-		Group("client_ip").
+		Select("? as frequency, CASE WHEN client_id != '' THEN client_id ELSE client_ip END as client, "+
+			"client_name, COUNT(*) as request_count, MAX(timestamp) as last_seen", 0).
+		Group(clientIdentity).
 		Order("request_count DESC").
 		Limit(5).
 		Scan(&rows).Error; err != nil {
@@ -524,7 +590,7 @@ func (r *repository) GetTopClients() ([]map[string]any, error) {
 	for _, r := range rows {
 		freq := float32(r.RequestCount) * 100 / float32(total)
 		clients = append(clients, map[string]any{
-			"client":     r.ClientIP,
+			"client":     r.Client,
 			"clientName": r.ClientName, "requestCount": r.RequestCount,
 			"frequency": freq,
 		})
@@ -532,18 +598,35 @@ func (r *repository) GetTopClients() ([]map[string]any, error) {
 	return clients, nil
 }
 
-func (r *repository) CountQueries(search string) (int, error) {
+func (r *repository) CountQueries(filter models.QueryFilter) (int, error) {
 	var total int64
-	err := r.db.Table("request_logs").
-		Where("domain LIKE ?", "%"+search+"%").
-		Count(&total).Error
+	err := applyQueryFilter(r.db.Table("request_logs"), filter).Count(&total).Error
 	return int(total), err
 }
 
-func (r *repository) UpdateClientName(ip, name string) error {
-	err := r.db.Model(&database.RequestLog{}).
-		Where("client_ip = ?", ip).
-		Update("client_name", name).Error
+// UpdateClientName names the client with the given IP address or client ID.
+func (r *repository) UpdateClientName(key, name string) error {
+	forClient := r.clientScope(key)
+	mac := ""
+	if !isClientID(key) {
+		mac = r.findDeviceMAC(key)
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.RequestLog{}).
+			Scopes(forClient).
+			Update("client_name", name).Error; err != nil {
+			return err
+		}
+
+		if isClientID(key) {
+			return upsertColumns(tx, &database.ClientIdentifier{ID: key, Name: name}, "id", "name")
+		}
+		if mac != "" {
+			return upsertColumns(tx, &database.MacAddress{MAC: mac, IP: key, Name: name}, "mac", "name")
+		}
+		return nil
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed whiled updating client name: %w", err)
@@ -552,13 +635,21 @@ func (r *repository) UpdateClientName(ip, name string) error {
 	return nil
 }
 
-func (r *repository) UpdateClientBypass(ip string, bypass bool) error {
-	err := r.db.Model(&database.MacAddress{}).
-		Where("ip = ?", ip).
-		Updates(map[string]any{
-			"bypass":     bypass,
-			"updated_at": time.Now(),
-		}).Error
+// UpdateClientBypass sets the bypass flag of the client with the given IP address or client ID.
+func (r *repository) UpdateClientBypass(key string, bypass bool) error {
+	var err error
+	if isClientID(key) {
+		err = upsertColumns(r.db, &database.ClientIdentifier{ID: key, Bypass: bypass}, "id", "bypass")
+	} else if mac := r.findDeviceMAC(key); mac != "" {
+		err = upsertColumns(r.db, &database.MacAddress{MAC: mac, IP: key, Bypass: bypass}, "mac", "bypass")
+	} else {
+		err = r.db.Model(&database.MacAddress{}).
+			Where("ip = ?", key).
+			Updates(map[string]any{
+				"bypass":     bypass,
+				"updated_at": time.Now(),
+			}).Error
+	}
 
 	if err != nil {
 		return fmt.Errorf("failed to update client bypass: %w", err)
@@ -567,6 +658,74 @@ func (r *repository) UpdateClientBypass(ip string, bypass bool) error {
 	return nil
 }
 
+// findDeviceMAC returns the MAC of the device currently using ip, or an empty string when it is unknown.
+func (r *repository) findDeviceMAC(ip string) string {
+	var mac string
+	err := r.db.Model(&database.MacAddress{}).
+		Select("mac").
+		Where("ip = ?", ip).
+		Order("updated_at DESC").
+		Limit(1).
+		Scan(&mac).Error
+	if err == nil && mac != "" {
+		return mac
+	}
+
+	err = r.db.Model(&database.RequestLog{}).
+		Select("client_mac").
+		Where("client_ip = ? AND client_mac != ''", ip).
+		Order("timestamp DESC").
+		Limit(1).
+		Scan(&mac).Error
+	if err != nil {
+		return ""
+	}
+	return mac
+}
+
+// clientScope selects the request logs of the client with the given IP address or client ID. For a
+// device with a known MAC this includes queries made from earlier addresses, and older logs from
+// before the MAC was recorded.
+func (r *repository) clientScope(key string) func(*gorm.DB) *gorm.DB {
+	if isClientID(key) {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where("client_id = ?", key)
+		}
+	}
+
+	mac := r.findDeviceMAC(key)
+	return func(db *gorm.DB) *gorm.DB {
+		if mac == "" {
+			return db.Where("client_id = '' AND client_ip = ?", key)
+		}
+		return db.Where("client_id = '' AND (client_mac = ? OR (client_mac = '' AND client_ip = ?))", mac, key)
+	}
+}
+
+// upsertColumns stores value, only updating the given columns when a row with the same key exists.
+func upsertColumns(db *gorm.DB, value any, key string, columns ...string) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: key}},
+		DoUpdates: clause.AssignmentColumns(append(columns, "updated_at")),
+	}).Create(value).Error
+}
+
+// isClientID reports whether key refers to a client by its client ID rather than its IP address.
+func isClientID(key string) bool {
+	if _, err := netip.ParseAddr(key); err == nil {
+		return false
+	}
+	return model.ValidClientID(key)
+}
+
+func deviceMAC(mac string) string {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return ""
+	}
+	return hw.String()
+}
+
 func (r *repository) DeleteRequestLogsTimebased(vacuum vacuumFunc, requestThreshold, maxRetries int, retryDelay time.Duration) error {
 	cutoffTime := time.Now().Add(-time.Duration(requestThreshold) * time.Second)
 
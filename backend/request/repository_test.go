@@ -0,0 +1,211 @@
+package request
+
+import (
+	"fmt"
+	"goaway/backend/api/models"
+	"goaway/backend/database"
+	model "goaway/backend/dns/server/models"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRepository(t *testing.T) *repository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, database.AutoMigrate(db))
+	return NewRepository(db)
+}
+
+func logEntry(ip, mac, domain string, at time.Time) model.RequestLogEntry {
+	return model.RequestLogEntry{
+		Timestamp:  at,
+		Domain:     domain,
+		Status:     "NOERROR",
+		QueryType:  "A",
+		Protocol:   model.UDP,
+		ClientInfo: &model.Client{IP: netip.MustParseAddr(ip), Name: "laptop", Mac: mac},
+	}
+}
+
+func TestClientIdentityFollowsMAC(t *testing.T) {
+	repo := setupRepository(t)
+	const mac = "aa:bb:cc:dd:ee:ff"
+	now := time.Now()
+
+	require.NoError(t, repo.db.Create(&database.MacAddress{MAC: mac, IP: "192.168.1.10"}).Error)
+	require.NoError(t, repo.SaveRequestLog([]model.RequestLogEntry{
+		logEntry("192.168.1.10", mac, "old.example.com", now.Add(-time.Hour)),
+		logEntry("192.168.1.20", "unknown", "other.example.com", now.Add(-time.Minute)),
+	}))
+
+	require.NoError(t, repo.UpdateClientBypass("192.168.1.10", true))
+	require.NoError(t, repo.UpdateClientName("192.168.1.10", "work-laptop"))
+
+	// The laptop gets a new address from DHCP
+	require.NoError(t, repo.db.Model(&database.MacAddress{}).Where("mac = ?", mac).Update("ip", "192.168.1.30").Error)
+	require.NoError(t, repo.SaveRequestLog([]model.RequestLogEntry{
+		logEntry("192.168.1.30", mac, "new.example.com", now),
+	}))
+
+	clients, err := repo.FetchAllClients()
+	require.NoError(t, err)
+	assert.Len(t, clients, 2)
+	assert.NotContains(t, clients, "192.168.1.10")
+
+	laptop := clients["192.168.1.30"]
+	assert.Equal(t, "work-laptop", laptop.Name)
+	assert.Equal(t, mac, laptop.Mac)
+	assert.True(t, laptop.Bypass)
+
+	history, err := repo.GetClientHistory("192.168.1.30")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	details, _, _, err := repo.GetClientDetailsWithDomains("192.168.1.30")
+	require.NoError(t, err)
+	assert.Equal(t, 2, details.TotalRequests)
+	assert.Equal(t, 2, repo.GetDistinctRequestIP())
+}
+
+func TestClientIDsAreSeparateClients(t *testing.T) {
+	repo := setupRepository(t)
+	now := time.Now()
+
+	phone := logEntry("203.0.113.5", "", "phone.example.com", now)
+	phone.ClientInfo.ID = "phone"
+	tablet := logEntry("203.0.113.5", "", "tablet.example.com", now.Add(-time.Second))
+	tablet.ClientInfo.ID = "tablet"
+	require.NoError(t, repo.SaveRequestLog([]model.RequestLogEntry{
+		phone, tablet, logEntry("203.0.113.5", "", "router.example.com", now.Add(-time.Minute)),
+	}))
+
+	require.NoError(t, repo.UpdateClientName("phone", "Alex's phone"))
+	require.NoError(t, repo.UpdateClientBypass("phone", true))
+
+	clients, err := repo.FetchAllClients()
+	require.NoError(t, err)
+	assert.Len(t, clients, 3)
+	assert.Equal(t, "Alex's phone", clients["phone"].Name)
+	assert.True(t, clients["phone"].Bypass)
+	assert.False(t, clients["tablet"].Bypass)
+	assert.Empty(t, clients["203.0.113.5"].ID)
+
+	history, err := repo.GetClientHistory("203.0.113.5")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	client, err := repo.FetchClient("phone")
+	require.NoError(t, err)
+	assert.Equal(t, "phone", client.ID)
+	assert.True(t, client.Bypass)
+}
+
+func TestFetchClientFallsBackToClientIdentifier(t *testing.T) {
+	repo := setupRepository(t)
+
+	require.NoError(t, repo.UpdateClientBypass("new-tablet", true))
+
+	client, err := repo.FetchClient("new-tablet")
+	require.NoError(t, err)
+	assert.Equal(t, "new-tablet", client.ID)
+	assert.Equal(t, "new-tablet", client.Name)
+	assert.True(t, client.Bypass)
+
+	require.NoError(t, repo.UpdateClientName("new-tablet", "Kitchen tablet"))
+	client, err = repo.FetchClient("new-tablet")
+	require.NoError(t, err)
+	assert.Equal(t, "Kitchen tablet", client.Name)
+	assert.True(t, client.Bypass)
+
+	_, err = repo.FetchClient("unknown")
+	assert.Error(t, err)
+}
+
+func TestFetchQueriesFiltersAndPaginates(t *testing.T) {
+	repo := setupRepository(t)
+	now := time.Now()
+
+	entries := []model.RequestLogEntry{
+		logEntry("192.168.1.10", "", "ads.example.com.", now.Add(-4*time.Minute)),
+		logEntry("192.168.1.10", "", "example.com.", now.Add(-3*time.Minute)),
+		logEntry("192.168.1.20", "", "www.example.com.", now.Add(-2*time.Minute)),
+		logEntry("192.168.1.20", "", "example.org.", now.Add(-time.Minute)),
+	}
+	entries[0].Blocked = true
+	entries[1].IP = []model.ResolvedIP{{IP: netip.MustParseAddr("93.184.215.14"), RType: "A"}}
+	entries[1].ResponseTime = 80 * time.Millisecond
+	entries[2].Protocol = model.DoH
+	entries[3].Status = "NXDOMAIN"
+	require.NoError(t, repo.SaveRequestLog(entries))
+
+	domains := func(q models.QueryParams) []string {
+		t.Helper()
+		if q.Column == "" {
+			q.Column, q.Direction = "timestamp", "DESC"
+		}
+		queries, err := repo.FetchQueries(q)
+		require.NoError(t, err)
+		var domains []string
+		for _, query := range queries {
+			domains = append(domains, query.Domain)
+		}
+		return domains
+	}
+	blocked := true
+
+	assert.Equal(t, []string{"example.com."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{Domain: "Example.com", ExactDomain: true}}))
+	assert.Len(t, domains(models.QueryParams{QueryFilter: models.QueryFilter{Domain: "example.com"}}), 3)
+	assert.Equal(t, []string{"ads.example.com."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{Blocked: &blocked}}))
+	assert.Equal(t, []string{"www.example.com."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{Protocols: []string{"doh"}}}))
+	assert.Equal(t, []string{"example.org."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{Statuses: []string{"nxdomain"}}}))
+	assert.Equal(t, []string{"example.com."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{ResolvedIP: "93.184.215.14"}}))
+	assert.Equal(t, []string{"example.com."}, domains(models.QueryParams{QueryFilter: models.QueryFilter{MinResponseTime: 50 * time.Millisecond}}))
+	assert.Equal(t, []string{"www.example.com.", "example.org."}, domains(models.QueryParams{
+		QueryFilter: models.QueryFilter{Client: "192.168.1.20"}, Column: "timestamp", Direction: "ASC",
+	}))
+
+	total, err := repo.CountQueries(models.QueryFilter{Domain: "example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	// Keyset pagination continues after the last query of the previous page
+	first, err := repo.FetchQueries(models.QueryParams{Column: "timestamp", Direction: "DESC", PageSize: 3})
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	assert.Equal(t, []string{"ads.example.com."}, domains(models.QueryParams{PageSize: 3, Cursor: first[2].ID}))
+
+	_, err = repo.FetchQueries(models.QueryParams{Column: "client_ip; DROP TABLE request_logs", Direction: "DESC"})
+	assert.Error(t, err)
+}
+
+func TestStreamQueriesReadsInBatches(t *testing.T) {
+	repo := setupRepository(t)
+	start := time.Now().Add(-time.Hour)
+
+	entries := make([]model.RequestLogEntry, streamBatchSize+5)
+	for i := range entries {
+		entries[i] = logEntry("192.168.1.10", "", fmt.Sprintf("%d.example.com.", i), start.Add(time.Duration(i)*time.Second))
+	}
+	require.NoError(t, repo.SaveRequestLog(entries))
+
+	var streamed []string
+	err := NewService(repo).StreamQueries(models.QueryFilter{Domain: "example.com"}, func(query model.RequestLogEntry) error {
+		streamed = append(streamed, query.Domain)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, streamed, len(entries))
+	assert.Equal(t, "0.example.com.", streamed[0])
+	assert.Equal(t, entries[len(entries)-1].Domain, streamed[len(streamed)-1])
+}
@@ -14,6 +14,8 @@ type Service struct {
 
 var log = logging.GetLogger()
 
+const streamBatchSize = 1000
+
 func NewService(repo Repository) *Service {
 	return &Service{repository: repo}
 }
@@ -47,6 +49,35 @@ func (s *Service) GetUniqueQueryTypes() ([]models.QueryTypeCount, error) {
 	return s.repository.GetUniqueQueryTypes()
 }
 
+// StreamQueries passes every query matching filter to fn, oldest first. Queries are read a batch at a
+// time, so that exports never hold the whole query log in memory.
+func (s *Service) StreamQueries(filter models.QueryFilter, fn func(model.RequestLogEntry) error) error {
+	params := models.QueryParams{QueryFilter: filter, Column: "timestamp", Direction: "ASC", PageSize: streamBatchSize}
+	for {
+		queries, err := s.repository.FetchQueries(params)
+		if err != nil {
+			return err
+		}
+
+		var last uint
+		for _, query := range queries {
+			// Queries which could not be read are left empty
+			if query.ID == 0 {
+				continue
+			}
+			if err := fn(query); err != nil {
+				return err
+			}
+			last = query.ID
+		}
+
+		if len(queries) < streamBatchSize || last == 0 {
+			return nil
+		}
+		params.Cursor = last
+	}
+}
+
 func (s *Service) FetchQueries(q models.QueryParams) ([]model.RequestLogEntry, error) {
 	return s.repository.FetchQueries(q)
 }
@@ -79,8 +110,8 @@ func (s *Service) GetTopClients() ([]map[string]interface{}, error) {
 	return s.repository.GetTopClients()
 }
 
-func (s *Service) CountQueries(search string) (int, error) {
-	return s.repository.CountQueries(search)
+func (s *Service) CountQueries(filter models.QueryFilter) (int, error) {
+	return s.repository.CountQueries(filter)
 }
 
 func (s *Service) UpdateClientName(ip string, name string) error {
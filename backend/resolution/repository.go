@@ -2,86 +2,75 @@ package resolution
 
 import (
 	"errors"
-	"goaway/backend/settings"
-	"strings"
+	"fmt"
+	"goaway/backend/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	CreateResolution(ip, domain string) error
-	FindResolution(domain string) (string, error)
-	FindResolutions() (map[string]string, error)
-	DeleteResolution(ip, domain string) (int, error)
+	CreateRecord(record *database.Resolution) error
+	FindRecords() ([]database.Resolution, error)
+	DeleteRecord(id uint) (database.Resolution, error)
+	DeleteRecordByValue(domain, value string) (int, error)
+	ImportRecords(records []database.Resolution) error
 }
 
 type repository struct {
+	db *gorm.DB
 }
 
-func NewRepository() Repository {
-	return &repository{}
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
 }
 
-func (r *repository) CreateResolution(ip, domain string) error {
-	cfg, err := settings.LoadSettings()
-	if err != nil {
-		return err
-	}
-	if cfg.DNS.Resolutions == nil {
-		cfg.DNS.Resolutions = make(map[string]string)
-	}
-
-	if _, exists := cfg.DNS.Resolutions[domain]; exists {
-		return errors.New("domain already exists, must be unique")
+func (r *repository) CreateRecord(record *database.Resolution) error {
+	result := r.db.Create(record)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%s %s record with that value already exists", record.Domain, record.Type)
+		}
+		return result.Error
 	}
 
-	cfg.DNS.Resolutions[domain] = ip
-	cfg.Save()
 	return nil
 }
 
-func (r *repository) FindResolution(domain string) (string, error) {
-	cfg, err := settings.LoadSettings()
-	if err != nil {
-		return "", err
-	}
-	if ip, ok := cfg.DNS.Resolutions[domain]; ok {
-		return ip, nil
+func (r *repository) FindRecords() ([]database.Resolution, error) {
+	var records []database.Resolution
+	if err := r.db.Order("domain, type, id").Find(&records).Error; err != nil {
+		return nil, err
 	}
 
-	parts := strings.Split(domain, ".")
-	for i := 1; i < len(parts); i++ {
-		wildcardDomain := "*." + strings.Join(parts[i:], ".")
-		if ip, ok := cfg.DNS.Resolutions[wildcardDomain]; ok {
-			return ip, nil
+	return records, nil
+}
+
+func (r *repository) DeleteRecord(id uint) (database.Resolution, error) {
+	var record database.Resolution
+	if err := r.db.First(&record, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, fmt.Errorf("record %d does not exist", id)
 		}
+		return record, err
+	}
+
+	if err := r.db.Delete(&record).Error; err != nil {
+		return record, err
 	}
 
-	return "", nil
+	return record, nil
 }
 
-func (r *repository) FindResolutions() (map[string]string, error) {
-	cfg, err := settings.LoadSettings()
-	if err != nil {
-		return nil, err
-	}
-	if cfg.DNS.Resolutions == nil {
-		return map[string]string{}, nil
-	}
-	return cfg.DNS.Resolutions, nil
+func (r *repository) DeleteRecordByValue(domain, value string) (int, error) {
+	result := r.db.Delete(&database.Resolution{}, "domain = ? AND value = ?", domain, value)
+	return int(result.RowsAffected), result.Error
 }
 
-func (r *repository) DeleteResolution(ip, domain string) (int, error) {
-	cfg, err := settings.LoadSettings()
-	if err != nil {
-		return 0, err
-	}
-	if cur, ok := cfg.DNS.Resolutions[domain]; ok {
-		if cur == ip {
-			delete(cfg.DNS.Resolutions, domain)
-			cfg.Save()
-			return 1, nil
-		}
-		return 0, nil
+func (r *repository) ImportRecords(records []database.Resolution) error {
+	if len(records) == 0 {
+		return nil
 	}
 
-	return 0, nil
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&records).Error
 }
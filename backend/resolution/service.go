@@ -1,33 +1,251 @@
 package resolution
 
 import (
+	"fmt"
+	"goaway/backend/database"
 	"goaway/backend/logging"
+	"goaway/backend/settings"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
 )
 
+var supportedTypes = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV", "PTR", "CAA"}
+
+type record struct {
+	rr  dns.RR
+	ttl uint32
+}
+
 type Service struct {
 	repository Repository
+
+	mu sync.RWMutex
+	// Records indexed by lowercase domain without trailing dot, wildcards are stored as "*.example.com"
+	index map[string][]record
 }
 
 var log = logging.GetLogger()
 
 func NewService(repo Repository) *Service {
-	return &Service{repository: repo}
+	service := &Service{
+		repository: repo,
+		index:      map[string][]record{},
+	}
+
+	if err := service.loadIndex(); err != nil {
+		log.Warning("Could not load local DNS records, %v", err)
+	}
+
+	return service
 }
 
-func (s *Service) CreateResolution(ip, domain string) error {
-	log.Debug("Creating new resolution '%s' -> '%s'", domain, ip)
-	return s.repository.CreateResolution(ip, domain)
+// ImportFromSettings moves resolutions from settings.yaml, where they were stored previously, into the database.
+func (s *Service) ImportFromSettings(config *settings.Config) error {
+	if len(config.DNS.Resolutions) == 0 {
+		return nil
+	}
+
+	records := make([]database.Resolution, 0, len(config.DNS.Resolutions))
+	for domain, ip := range config.DNS.Resolutions {
+		record, err := newRecord(domain, "", ip, 0)
+		if err != nil {
+			log.Warning("Skipping resolution '%s' -> '%s' from settings: %v", domain, ip, err)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if err := s.repository.ImportRecords(records); err != nil {
+		return fmt.Errorf("failed to import resolutions: %w", err)
+	}
+
+	log.Info("Moved %d resolutions from settings into the database", len(records))
+	config.DNS.Resolutions = nil
+	config.Save()
+
+	return s.loadIndex()
 }
 
-func (s *Service) GetResolution(domain string) (string, error) {
-	log.Debug("Finding resolution for domain: %s", domain)
-	return s.repository.FindResolution(domain)
+func (s *Service) CreateRecord(domain, recordType, value string, ttl uint32) (database.Resolution, error) {
+	record, err := newRecord(domain, recordType, value, ttl)
+	if err != nil {
+		return database.Resolution{}, err
+	}
+
+	if err := s.checkCNAMEConflict(record); err != nil {
+		return database.Resolution{}, err
+	}
+
+	log.Debug("Creating new %s record '%s' -> '%s'", record.Type, record.Domain, record.Value)
+	if err := s.repository.CreateRecord(&record); err != nil {
+		return database.Resolution{}, err
+	}
+
+	return record, s.loadIndex()
+}
+
+func (s *Service) GetRecords() ([]database.Resolution, error) {
+	return s.repository.FindRecords()
+}
+
+func (s *Service) DeleteRecord(id uint) (database.Resolution, error) {
+	record, err := s.repository.DeleteRecord(id)
+	if err != nil {
+		return record, err
+	}
+
+	return record, s.loadIndex()
+}
+
+// DeleteResolution removes the records for domain with the given value.
+func (s *Service) DeleteResolution(value, domain string) (int, error) {
+	removed, err := s.repository.DeleteRecordByValue(normalizeDomain(domain), value)
+	if err != nil || removed == 0 {
+		return removed, err
+	}
+
+	return removed, s.loadIndex()
+}
+
+// Lookup returns the local records for name matching qtype, falling back to wildcard records.
+// When the name only has a CNAME, the CNAME is returned for any qtype so the caller can follow it.
+// found is true whenever the name has local records, even if none match qtype.
+func (s *Service) Lookup(name string, qtype uint16, defaultTTL uint32) (answers []dns.RR, found bool) {
+	domain := normalizeDomain(name)
+
+	s.mu.RLock()
+	records, found := s.index[domain]
+	if !found {
+		parts := strings.Split(domain, ".")
+		for i := 1; i < len(parts) && !found; i++ {
+			records, found = s.index["*."+strings.Join(parts[i:], ".")]
+		}
+	}
+	s.mu.RUnlock()
+
+	if !found {
+		return nil, false
+	}
+
+	for _, r := range records {
+		rrType := dns.RRToType(r.rr)
+		if rrType != qtype && rrType != dns.TypeCNAME {
+			continue
+		}
+
+		answer := r.rr.Clone()
+		answer.Header().Name = dnsutil.Fqdn(name)
+		answer.Header().TTL = r.ttl
+		if r.ttl == 0 {
+			answer.Header().TTL = defaultTTL
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, true
+}
+
+func (s *Service) loadIndex() error {
+	records, err := s.repository.FindRecords()
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string][]record, len(records))
+	for _, r := range records {
+		rr, err := buildRR(r.Domain, r.Type, r.Value)
+		if err != nil {
+			log.Warning("Ignoring invalid %s record for '%s': %v", r.Type, r.Domain, err)
+			continue
+		}
+		index[r.Domain] = append(index[r.Domain], record{rr: rr, ttl: r.TTL})
+	}
+
+	s.mu.Lock()
+	s.index = index
+	s.mu.Unlock()
+	return nil
+}
+
+// checkCNAMEConflict enforces that a name with a CNAME has no other records, as required by RFC 1034.
+func (s *Service) checkCNAMEConflict(r database.Resolution) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, existing := range s.index[r.Domain] {
+		isCNAME := dns.RRToType(existing.rr) == dns.TypeCNAME
+		if isCNAME || r.Type == "CNAME" {
+			return fmt.Errorf("%s already has records, a CNAME can not be combined with other records", r.Domain)
+		}
+	}
+
+	return nil
+}
+
+func newRecord(domain, recordType, value string, ttl uint32) (database.Resolution, error) {
+	domain = normalizeDomain(domain)
+	recordType = strings.ToUpper(strings.TrimSpace(recordType))
+	value = strings.TrimSpace(value)
+
+	if domain == "" || value == "" {
+		return database.Resolution{}, fmt.Errorf("domain and value are required")
+	}
+
+	if recordType == "" {
+		ip, err := netip.ParseAddr(value)
+		if err != nil {
+			return database.Resolution{}, fmt.Errorf("record type is required when value is not an IP address")
+		}
+		recordType = "A"
+		if ip.Is6() {
+			recordType = "AAAA"
+		}
+	}
+
+	if !slices.Contains(supportedTypes, recordType) {
+		return database.Resolution{}, fmt.Errorf("unsupported record type '%s', supported types are %s", recordType, strings.Join(supportedTypes, ", "))
+	}
+
+	switch recordType {
+	case "A", "AAAA":
+		ip, err := netip.ParseAddr(value)
+		if err != nil || (recordType == "A") != ip.Is4() {
+			return database.Resolution{}, fmt.Errorf("'%s' is not a valid %s address", value, recordType)
+		}
+	case "TXT":
+		if !strings.HasPrefix(value, `"`) {
+			value = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+		}
+	}
+
+	if _, err := buildRR(domain, recordType, value); err != nil {
+		return database.Resolution{}, fmt.Errorf("invalid %s record value '%s': %w", recordType, value, err)
+	}
+
+	return database.Resolution{
+		Domain: domain,
+		Type:   recordType,
+		Value:  value,
+		TTL:    ttl,
+	}, nil
 }
 
-func (s *Service) GetResolutions() (map[string]string, error) {
-	return s.repository.FindResolutions()
+func buildRR(domain, recordType, value string) (dns.RR, error) {
+	rr, err := dns.New(fmt.Sprintf("%s IN %s %s", dnsutil.Fqdn(domain), recordType, value))
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty record")
+	}
+	return rr, nil
 }
 
-func (s *Service) DeleteResolution(ip, domain string) (int, error) {
-	return s.repository.DeleteResolution(ip, domain)
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
 }
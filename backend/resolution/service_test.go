@@ -0,0 +1,112 @@
+package resolution
+
+import (
+	"goaway/backend/database"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+)
+
+type memoryRepository struct {
+	records []database.Resolution
+}
+
+func (r *memoryRepository) CreateRecord(record *database.Resolution) error {
+	record.ID = uint(len(r.records) + 1)
+	r.records = append(r.records, *record)
+	return nil
+}
+
+func (r *memoryRepository) FindRecords() ([]database.Resolution, error) {
+	return r.records, nil
+}
+
+func (r *memoryRepository) DeleteRecord(id uint) (database.Resolution, error) {
+	return database.Resolution{}, nil
+}
+
+func (r *memoryRepository) DeleteRecordByValue(domain, value string) (int, error) {
+	return 0, nil
+}
+
+func (r *memoryRepository) ImportRecords(records []database.Resolution) error {
+	r.records = append(r.records, records...)
+	return nil
+}
+
+func TestLookup(t *testing.T) {
+	service := NewService(&memoryRepository{})
+
+	records := []struct {
+		domain, recordType, value string
+	}{
+		{"nas.lan", "", "192.168.1.10"},
+		{"nas.lan", "A", "192.168.1.11"},
+		{"nas.lan", "AAAA", "fd00::10"},
+		{"nas.lan", "TXT", "v=spf1 -all"},
+		{"mail.lan", "MX", "10 nas.lan."},
+		{"_sip._tcp.lan", "SRV", "10 5 5060 nas.lan."},
+		{"lan", "CAA", `0 issue "letsencrypt.org"`},
+		{"10.1.168.192.in-addr.arpa", "PTR", "nas.lan."},
+		{"files.lan", "CNAME", "nas.lan."},
+		{"*.apps.lan", "A", "192.168.1.20"},
+	}
+	for _, r := range records {
+		if _, err := service.CreateRecord(r.domain, r.recordType, r.value, 0); err != nil {
+			t.Fatalf("CreateRecord(%s %s) failed: %v", r.domain, r.recordType, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		qtype   uint16
+		answers int
+		found   bool
+	}{
+		{"nas.lan.", dns.TypeA, 2, true},
+		{"NAS.lan.", dns.TypeAAAA, 1, true},
+		{"nas.lan.", dns.TypeMX, 0, true},
+		{"nas.lan.", dns.TypeTXT, 1, true},
+		{"mail.lan.", dns.TypeMX, 1, true},
+		{"_sip._tcp.lan.", dns.TypeSRV, 1, true},
+		{"lan.", dns.TypeCAA, 1, true},
+		{"10.1.168.192.in-addr.arpa.", dns.TypePTR, 1, true},
+		{"files.lan.", dns.TypeA, 1, true},
+		{"grafana.apps.lan.", dns.TypeA, 1, true},
+		{"unknown.lan.", dns.TypeA, 0, false},
+	}
+	for _, tt := range tests {
+		answers, found := service.Lookup(tt.name, tt.qtype, 60)
+		if found != tt.found || len(answers) != tt.answers {
+			t.Errorf("Lookup(%s, %s) = %d answers, found %v; want %d, %v",
+				tt.name, dns.TypeToString[tt.qtype], len(answers), found, tt.answers, tt.found)
+		}
+		for _, answer := range answers {
+			if answer.Header().Name != dnsutil.Fqdn(tt.name) || answer.Header().TTL != 60 {
+				t.Errorf("Lookup(%s) returned unexpected header %s", tt.name, answer.Header())
+			}
+		}
+	}
+}
+
+func TestCreateRecordValidation(t *testing.T) {
+	service := NewService(&memoryRepository{})
+
+	if _, err := service.CreateRecord("host.lan", "A", "fd00::1", 0); err == nil {
+		t.Error("expected IPv6 address to be rejected for A record")
+	}
+	if _, err := service.CreateRecord("host.lan", "NS", "ns.lan.", 0); err == nil {
+		t.Error("expected unsupported record type to be rejected")
+	}
+	if _, err := service.CreateRecord("host.lan", "", "not-an-ip", 0); err == nil {
+		t.Error("expected missing type with non-IP value to be rejected")
+	}
+
+	if _, err := service.CreateRecord("host.lan", "A", "10.0.0.1", 0); err != nil {
+		t.Fatalf("CreateRecord failed: %v", err)
+	}
+	if _, err := service.CreateRecord("host.lan", "CNAME", "other.lan.", 0); err == nil {
+		t.Error("expected CNAME next to other records to be rejected")
+	}
+}
@@ -2,20 +2,23 @@ package services
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
+	"goaway/backend/certificate"
 	"goaway/backend/database"
 	"goaway/backend/dns/server"
 	"goaway/backend/settings"
+	"goaway/backend/syslog"
+	"path/filepath"
 
 	"gorm.io/gorm"
 )
 
 type AppContext struct {
-	Config      *settings.Config
-	DBConn      *gorm.DB
-	Certificate tls.Certificate
-	DNSServer   *server.DNSServer
+	Config       *settings.Config
+	DBConn       *gorm.DB
+	Certificates *certificate.Service
+	DNSServer    *server.DNSServer
+	Syslog       *syslog.Forwarder
 }
 
 func NewAppContext(config *settings.Config) (*AppContext, error) {
@@ -30,22 +33,27 @@ func NewAppContext(config *settings.Config) (*AppContext, error) {
 func (ctx *AppContext) initialize() error {
 	ctx.DBConn = database.Initialize()
 
-	cert, err := ctx.Config.GetCertificate()
+	certificates, err := certificate.NewService(ctx.Config.DNS.TLS, filepath.Join("config", "certs"))
 	if err != nil {
 		return fmt.Errorf("failed to get certificate: %w", err)
 	}
-	ctx.Certificate = cert
+	ctx.Certificates = certificates
 
 	dnsServer, err := server.NewDNSServer(
 		ctx.Config,
 		ctx.DBConn,
-		cert,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize DNS server: %w", err)
 	}
 	ctx.DNSServer = dnsServer
 
+	ctx.Syslog = syslog.NewForwarder()
+	if err := ctx.Syslog.Reload(ctx.Config.Syslog); err != nil {
+		return fmt.Errorf("failed to start syslog forwarding: %w", err)
+	}
+	dnsServer.Syslog = ctx.Syslog
+
 	go dnsServer.ProcessLogEntries(context.Background())
 
 	return nil
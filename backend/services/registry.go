@@ -3,10 +3,12 @@ package services
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"goaway/backend/api"
 	"goaway/backend/api/key"
 	"goaway/backend/blacklist"
+	"goaway/backend/dns/server"
 	"goaway/backend/logging"
 	"goaway/backend/notification"
 	"goaway/backend/prefetch"
@@ -18,6 +20,7 @@ import (
 	"sync"
 
 	"codeberg.org/miekg/dns"
+	"github.com/quic-go/quic-go/http3"
 )
 
 var log = logging.GetLogger()
@@ -29,10 +32,12 @@ type ServiceRegistry struct {
 	readyChan chan struct{}
 	content   embed.FS
 
-	UDPServer *dns.Server
-	TCPServer *dns.Server
-	DoTServer *dns.Server
-	DoHServer *http.Server
+	UDPServer  *dns.Server
+	TCPServer  *dns.Server
+	DoTServer  *dns.Server
+	DoHServer  *http.Server
+	DoH3Server *http3.Server
+	DoQServer  *server.DoQServer
 
 	Context *AppContext
 
@@ -76,12 +81,18 @@ func NewServiceRegistry(ctx *AppContext, version, commit, date string, content e
 func (r *ServiceRegistry) Initialize() error {
 	r.setupDNSServers()
 
-	if r.Context.Certificate.Certificate != nil {
+	if r.Context.Certificates.Available() {
 		if err := r.setupSecureServers(); err != nil {
 			return err
 		}
 	}
 
+	if r.Context.Certificates.Available() || r.Context.Config.DNS.DoH.PlainHTTP {
+		if err := r.setupDoHServer(); err != nil {
+			return err
+		}
+	}
+
 	r.setupAPIServer()
 
 	return nil
@@ -114,18 +125,35 @@ func (r *ServiceRegistry) setupDNSServers() {
 }
 
 func (r *ServiceRegistry) setupSecureServers() error {
-	dotServer, err := r.Context.DNSServer.InitDoT(r.Context.Certificate)
+	dotServer, err := r.Context.DNSServer.InitDoT(r.Context.Certificates)
 	if err != nil {
 		return fmt.Errorf("failed to initialize DoT server: %w", err)
 	}
 	r.DoTServer = dotServer
 
-	dohServer, err := r.Context.DNSServer.InitDoH(r.Context.Certificate)
+	if r.Context.Config.DNS.Ports.DoQ != 0 {
+		doqServer, err := r.Context.DNSServer.InitDoQ(r.Context.Certificates)
+		if err != nil {
+			return fmt.Errorf("failed to initialize DoQ server: %w", err)
+		}
+		r.DoQServer = doqServer
+	}
+
+	return nil
+}
+
+// setupDoHServer creates the DoH server, which needs no certificate when TLS is terminated by a reverse proxy.
+func (r *ServiceRegistry) setupDoHServer() error {
+	dohServer, err := r.Context.DNSServer.InitDoH(r.Context.Certificates)
 	if err != nil {
 		return fmt.Errorf("failed to initialize DoH server: %w", err)
 	}
 	r.DoHServer = dohServer
 
+	if dohServer.TLSConfig != nil {
+		r.DoH3Server = r.Context.DNSServer.InitDoH3(dohServer)
+	}
+
 	return nil
 }
 
@@ -151,16 +179,22 @@ func (r *ServiceRegistry) setupAPIServer() {
 		KeyService:          r.KeyService,
 		BlacklistService:    r.BlacklistService,
 		WhitelistService:    r.WhitelistService,
+		Certificates:        r.Context.Certificates,
+		Syslog:              r.Context.Syslog,
 	}
 }
 
 func (r *ServiceRegistry) StartAll() {
 	r.startDNSServers()
 
-	if r.Context.Certificate.Certificate != nil {
+	if r.Context.Certificates.Available() {
 		r.startSecureServers()
 	}
 
+	if r.DoHServer != nil {
+		r.startDoHServer()
+	}
+
 	r.startAPIServer()
 }
 
@@ -191,24 +225,56 @@ func (r *ServiceRegistry) startSecureServers() {
 		}
 	}()
 
+	if r.DoQServer != nil {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			if err := r.DoQServer.ListenAndServe(); err != nil {
+				r.errorChan <- ServiceError{Service: "DoQ", Err: err}
+			}
+		}()
+	}
+}
+
+func (r *ServiceRegistry) startDoHServer() {
+	config := r.Context.Config
+	scheme := "https"
+	if config.DNS.DoH.PlainHTTP {
+		scheme = "http"
+	}
+
 	r.wg.Add(1)
 	go func() {
 		defer r.wg.Done()
 
 		if serverIP, err := api.GetServerIP(); err == nil {
-			log.Info("DoH (dns-over-https) server running at https://%s:%d/dns-query",
-				serverIP, r.Context.Config.DNS.Ports.DoH)
+			log.Info("DoH (dns-over-https) server running at %s://%s:%d%s",
+				scheme, serverIP, config.DNS.Ports.DoH, r.Context.DNSServer.DoHPath())
 		} else {
-			log.Info("DoH (dns-over-https) server running on port :%d", r.Context.Config.DNS.Ports.DoH)
+			log.Info("DoH (dns-over-https) server running on port :%d", config.DNS.Ports.DoH)
 		}
 
-		if err := r.DoHServer.ListenAndServeTLS(
-			r.Context.Config.DNS.TLS.Cert,
-			r.Context.Config.DNS.TLS.Key,
-		); err != nil {
+		var err error
+		if config.DNS.DoH.PlainHTTP {
+			err = r.DoHServer.ListenAndServe()
+		} else {
+			err = r.DoHServer.ListenAndServeTLS("", "")
+		}
+		if err != nil {
 			r.errorChan <- ServiceError{Service: "DoH", Err: err}
 		}
 	}()
+
+	if r.DoH3Server != nil {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			// DoH keeps working over TCP without HTTP/3, so this is not fatal
+			if err := r.DoH3Server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Warning("DoH over HTTP/3 is unavailable: %v", err)
+			}
+		}()
+	}
 }
 
 func (r *ServiceRegistry) startAPIServer() {
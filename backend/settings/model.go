@@ -8,10 +8,25 @@ type Status struct {
 	Paused    bool      `json:"paused"`
 }
 
+// ServerName is the hostname DoT and DoH are served under. DoT clients connecting to
+// "<client-id>.<serverName>" are identified by their client ID.
+// When enabled without a Cert and Key, a local CA and a certificate signed by it are generated.
 type TLSConfig struct {
-	Enabled bool   `yaml:"enabled" json:"enabled"`
-	Cert    string `yaml:"cert" json:"cert"`
-	Key     string `yaml:"key" json:"key"`
+	Enabled    bool       `yaml:"enabled" json:"enabled"`
+	Cert       string     `yaml:"cert" json:"cert"`
+	Key        string     `yaml:"key" json:"key"`
+	ServerName string     `yaml:"serverName" json:"serverName"`
+	ACME       ACMEConfig `yaml:"acme" json:"acme"`
+}
+
+// ACMEConfig obtains certificates for Domains from an ACME CA, Let's Encrypt unless Directory is set.
+// CA is a PEM file of the CA the directory is served with, for private ACME servers.
+type ACMEConfig struct {
+	Enabled   bool     `yaml:"enabled" json:"enabled"`
+	Directory string   `yaml:"directory" json:"directory"`
+	Email     string   `yaml:"email" json:"email"`
+	Domains   []string `yaml:"domains" json:"domains"`
+	CA        string   `yaml:"ca" json:"ca"`
 }
 
 type UpstreamConfig struct {
@@ -23,18 +38,74 @@ type PortsConfig struct {
 	TCPUDP int `yaml:"udptcp" json:"udptcp"`
 	DoT    int `yaml:"dot" json:"dot"`
 	DoH    int `yaml:"doh" json:"doh"`
+	DoQ    int `yaml:"doq" json:"doq"`
+}
+
+// SafeSearchConfig rewrites queries for supported search engines and YouTube to their enforced safe-search hostnames.
+// Clients accepts single IPs and CIDR ranges, leave it empty to apply to every client.
+type SafeSearchConfig struct {
+	Enabled bool     `yaml:"enabled" json:"enabled"`
+	Clients []string `yaml:"clients" json:"clients"`
+}
+
+// DoHConfig controls the DoH server. Path is where wire format queries are answered, "/dns-query" when unset.
+// With PlainHTTP, DoH is served without TLS for use behind a TLS-terminating reverse proxy.
+// The X-Forwarded-For and Forwarded headers of requests from TrustedProxies, IPs or CIDR ranges,
+// determine the client address.
+type DoHConfig struct {
+	Path           string   `yaml:"path" json:"path"`
+	PlainHTTP      bool     `yaml:"plainHTTP" json:"plainHTTP"`
+	TrustedProxies []string `yaml:"trustedProxies" json:"trustedProxies"`
+}
+
+// AccessConfig restricts which clients are answered. Allow and Deny hold IPs, CIDR ranges and client IDs.
+// When Allow is set only clients matching it are answered, and Deny takes precedence over Allow.
+// Denied queries are answered REFUSED, or not at all when Action is "drop".
+type AccessConfig struct {
+	Allow  []string `yaml:"allow" json:"allow"`
+	Deny   []string `yaml:"deny" json:"deny"`
+	Action string   `yaml:"action" json:"action"`
+}
+
+// DNSRateLimitConfig limits the queries of each client to QueriesPerSecond, with bursts of up to Burst queries.
+// Clients are limited per subnet of IPv4Prefix and IPv6Prefix bits. ResponsesPerSecond limits identical
+// UDP responses sent to a subnet (RRL), every Slip-th response over the limit is sent truncated instead of
+// dropped. Zero rates disable the respective limit.
+type DNSRateLimitConfig struct {
+	Enabled            bool    `yaml:"enabled" json:"enabled"`
+	QueriesPerSecond   float64 `yaml:"queriesPerSecond" json:"queriesPerSecond"`
+	Burst              int     `yaml:"burst" json:"burst"`
+	IPv4Prefix         int     `yaml:"ipv4Prefix" json:"ipv4Prefix"`
+	IPv6Prefix         int     `yaml:"ipv6Prefix" json:"ipv6Prefix"`
+	ResponsesPerSecond float64 `yaml:"responsesPerSecond" json:"responsesPerSecond"`
+	Slip               int     `yaml:"slip" json:"slip"`
+}
+
+// DnstapConfig sends every query and response as dnstap messages. Network is one of "unix", "tcp" or "file",
+// with Address being the socket path, host:port or file path. Identity defaults to the hostname.
+type DnstapConfig struct {
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	Network  string `yaml:"network" json:"network"`
+	Address  string `yaml:"address" json:"address"`
+	Identity string `yaml:"identity" json:"identity"`
 }
 
 type DNSConfig struct {
-	Status      Status            `yaml:"-" json:"status"`
-	Address     string            `yaml:"address" json:"address"`
-	Gateway     string            `yaml:"gateway" json:"gateway"`
-	CacheTTL    int               `yaml:"cacheTTL" json:"cacheTTL"`
-	UDPSize     int               `yaml:"udpSize" json:"udpSize"`
-	TLS         TLSConfig         `yaml:"tls" json:"tls"`
-	Upstream    UpstreamConfig    `yaml:"upstream" json:"upstream"`
-	Resolutions map[string]string `yaml:"resolution" json:"resolution"`
-	Ports       PortsConfig       `yaml:"ports" json:"ports"`
+	Status   Status         `yaml:"-" json:"status"`
+	Address  string         `yaml:"address" json:"address"`
+	Gateway  string         `yaml:"gateway" json:"gateway"`
+	CacheTTL int            `yaml:"cacheTTL" json:"cacheTTL"`
+	UDPSize  int            `yaml:"udpSize" json:"udpSize"`
+	TLS      TLSConfig      `yaml:"tls" json:"tls"`
+	DoH      DoHConfig      `yaml:"doh" json:"doh"`
+	Upstream UpstreamConfig `yaml:"upstream" json:"upstream"`
+	// Deprecated: resolutions are stored in the database, entries found here are moved there on startup
+	Resolutions map[string]string  `yaml:"resolution,omitempty" json:"-"`
+	Ports       PortsConfig        `yaml:"ports" json:"ports"`
+	SafeSearch  SafeSearchConfig   `yaml:"safeSearch" json:"safeSearch"`
+	Access      AccessConfig       `yaml:"access" json:"access"`
+	RateLimit   DNSRateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	Dnstap      DnstapConfig       `yaml:"dnstap" json:"dnstap"`
 }
 
 type RateLimitConfig struct {
@@ -43,11 +114,18 @@ type RateLimitConfig struct {
 	Window   int  `yaml:"window" json:"window"`
 }
 
+// MetricsConfig exposes Prometheus metrics at /metrics. RequireKey only answers requests with a valid API key.
+type MetricsConfig struct {
+	Enabled    bool `yaml:"enabled" json:"enabled"`
+	RequireKey bool `yaml:"requireKey" json:"requireKey"`
+}
+
 type APIConfig struct {
 	Port           int             `yaml:"port" json:"port"`
 	Authentication bool            `yaml:"authentication" json:"authentication"`
 	JWTSecret      string          `yaml:"jwtSecret" json:"-"`
 	RateLimit      RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	Metrics        MetricsConfig   `yaml:"metrics" json:"metrics"`
 }
 
 type LoggingConfig struct {
@@ -55,6 +133,27 @@ type LoggingConfig struct {
 	Level   int  `yaml:"level" json:"level"`
 }
 
+// QueryLogConfig controls how query log entries are queued before being saved. Overflow is what happens
+// to entries when QueueSize entries are already waiting, one of "dropOldest", "dropNewest" or "spill".
+type QueryLogConfig struct {
+	QueueSize int    `yaml:"queueSize" json:"queueSize"`
+	Overflow  string `yaml:"overflow" json:"overflow"`
+}
+
+// SyslogConfig forwards query log entries, audit entries and notifications to a remote syslog server as
+// RFC 5424 messages. Network is one of "udp", "tcp" or "tls", Facility a name such as "local0" and Format
+// is "text" for key=value pairs or "json". CAFile verifies a TLS server signed by a private CA.
+// Sources is any of "queries", "audit" and "notifications", all of them when unset.
+type SyslogConfig struct {
+	Enabled  bool     `yaml:"enabled" json:"enabled"`
+	Network  string   `yaml:"network" json:"network"`
+	Address  string   `yaml:"address" json:"address"`
+	CAFile   string   `yaml:"caFile" json:"caFile"`
+	Facility string   `yaml:"facility" json:"facility"`
+	Format   string   `yaml:"format" json:"format"`
+	Sources  []string `yaml:"sources" json:"sources"`
+}
+
 type MiscConfig struct {
 	InAppUpdate               bool `yaml:"inAppUpdate" json:"inAppUpdate"`
 	StatisticsRetention       int  `yaml:"statisticsRetention" json:"statisticsRetention"`
@@ -62,10 +161,30 @@ type MiscConfig struct {
 	ScheduledBlacklistUpdates bool `yaml:"scheduledBlacklistUpdates" json:"scheduledBlacklistUpdates"`
 }
 
+// LeaseFileConfig points to the lease file of a local DHCP server.
+// Format is one of "dnsmasq", "isc", "kea" or "odhcpd".
+type LeaseFileConfig struct {
+	Path   string `yaml:"path" json:"path"`
+	Format string `yaml:"format" json:"format"`
+}
+
+// ClientsConfig controls how clients are identified.
+// HostnameLookup is the ordered list of methods used to find a client's hostname, any of
+// "reverse", "mdns", "netbios" and "ssh". When unset, "reverse", "mdns" and "ssh" are used.
+// VendorRegistry is an IEEE registry CSV file replacing the embedded vendor registry.
+type ClientsConfig struct {
+	Leases         []LeaseFileConfig `yaml:"leases" json:"leases"`
+	HostnameLookup []string          `yaml:"hostnameLookup" json:"hostnameLookup"`
+	VendorRegistry string            `yaml:"vendorRegistry" json:"vendorRegistry"`
+}
+
 type Config struct {
-	BinaryPath string        `yaml:"-" json:"-"`
-	DNS        DNSConfig     `yaml:"dns" json:"dns"`
-	API        APIConfig     `yaml:"api" json:"api"`
-	Logging    LoggingConfig `yaml:"logging" json:"logging"`
-	Misc       MiscConfig    `yaml:"misc" json:"misc"`
+	BinaryPath string         `yaml:"-" json:"-"`
+	DNS        DNSConfig      `yaml:"dns" json:"dns"`
+	API        APIConfig      `yaml:"api" json:"api"`
+	Clients    ClientsConfig  `yaml:"clients" json:"clients"`
+	Logging    LoggingConfig  `yaml:"logging" json:"logging"`
+	QueryLog   QueryLogConfig `yaml:"queryLog" json:"queryLog"`
+	Syslog     SyslogConfig   `yaml:"syslog" json:"syslog"`
+	Misc       MiscConfig     `yaml:"misc" json:"misc"`
 }
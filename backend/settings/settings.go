@@ -2,7 +2,6 @@ package settings
 
 import (
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"goaway/backend/logging"
@@ -68,6 +67,7 @@ func (config *Config) Update(updatedSettings Config) {
 	config.API.Port = updatedSettings.API.Port
 	config.API.Authentication = updatedSettings.API.Authentication
 	config.API.RateLimit = updatedSettings.API.RateLimit
+	config.API.Metrics = updatedSettings.API.Metrics
 
 	config.DNS.Address = updatedSettings.DNS.Address
 	config.DNS.Gateway = updatedSettings.DNS.Gateway
@@ -75,10 +75,17 @@ func (config *Config) Update(updatedSettings Config) {
 	config.DNS.UDPSize = updatedSettings.DNS.UDPSize
 	config.DNS.CacheTTL = updatedSettings.DNS.CacheTTL
 	config.DNS.TLS = updatedSettings.DNS.TLS
+	config.DNS.DoH = updatedSettings.DNS.DoH
 	config.DNS.Upstream = updatedSettings.DNS.Upstream
-	config.DNS.Resolutions = updatedSettings.DNS.Resolutions
+	config.DNS.SafeSearch = updatedSettings.DNS.SafeSearch
+	config.DNS.Access = updatedSettings.DNS.Access
+	config.DNS.RateLimit = updatedSettings.DNS.RateLimit
+	config.DNS.Dnstap = updatedSettings.DNS.Dnstap
 
+	config.Clients = updatedSettings.Clients
 	config.Logging = updatedSettings.Logging
+	config.QueryLog = updatedSettings.QueryLog
+	config.Syslog = updatedSettings.Syslog
 	config.Misc = updatedSettings.Misc
 
 	log.ToggleLogging(config.Logging.Enabled)
@@ -109,6 +116,11 @@ func createDefaultSettings(filePath string) (Config, error) {
 				Cert:    "",
 				Key:     "",
 			},
+			DoH: DoHConfig{
+				Path:           "/dns-query",
+				PlainHTTP:      false,
+				TrustedProxies: []string{},
+			},
 			Upstream: UpstreamConfig{
 				Preferred: "8.8.8.8:53",
 				Fallback: []string{
@@ -119,8 +131,30 @@ func createDefaultSettings(filePath string) (Config, error) {
 				TCPUDP: getEnvAsIntWithDefault("DNS_PORT", 53),
 				DoT:    getEnvAsIntWithDefault("DOT_PORT", 853),
 				DoH:    getEnvAsIntWithDefault("DOH_PORT", 443),
+				DoQ:    getEnvAsIntWithDefault("DOQ_PORT", 853),
+			},
+			SafeSearch: SafeSearchConfig{
+				Enabled: false,
+				Clients: []string{},
+			},
+			Access: AccessConfig{
+				Allow:  []string{},
+				Deny:   []string{},
+				Action: "refuse",
+			},
+			RateLimit: DNSRateLimitConfig{
+				Enabled:            false,
+				QueriesPerSecond:   50,
+				Burst:              200,
+				IPv4Prefix:         32,
+				IPv6Prefix:         56,
+				ResponsesPerSecond: 0,
+				Slip:               2,
+			},
+			Dnstap: DnstapConfig{
+				Enabled: false,
+				Network: "unix",
 			},
-			Resolutions: map[string]string{},
 		},
 		API: APIConfig{
 			Port:           getEnvAsIntWithDefault("WEBSITE_PORT", 8080),
@@ -131,11 +165,30 @@ func createDefaultSettings(filePath string) (Config, error) {
 				MaxTries: 5,
 				Window:   5,
 			},
+			Metrics: MetricsConfig{
+				Enabled:    false,
+				RequireKey: true,
+			},
+		},
+		Clients: ClientsConfig{
+			Leases:         []LeaseFileConfig{},
+			HostnameLookup: []string{"reverse", "mdns", "ssh"},
 		},
 		Logging: LoggingConfig{
 			Enabled: true,
 			Level:   int(logging.INFO),
 		},
+		QueryLog: QueryLogConfig{
+			QueueSize: 1000,
+			Overflow:  "dropOldest",
+		},
+		Syslog: SyslogConfig{
+			Enabled:  false,
+			Network:  "udp",
+			Facility: "local0",
+			Format:   "text",
+			Sources:  []string{"queries", "audit", "notifications"},
+		},
 		Misc: MiscConfig{
 			InAppUpdate:               false,
 			StatisticsRetention:       7,
@@ -176,19 +229,6 @@ func getEnvAsIntWithDefault(envVariable string, defaultValue int) int {
 	return intVal
 }
 
-func (config *Config) GetCertificate() (tls.Certificate, error) {
-	if config.DNS.TLS.Enabled && config.DNS.TLS.Cert != "" && config.DNS.TLS.Key != "" {
-		cert, err := tls.LoadX509KeyPair(config.DNS.TLS.Cert, config.DNS.TLS.Key)
-		if err != nil {
-			return tls.Certificate{}, fmt.Errorf("failed to load TLS certificate: %w", err)
-		}
-
-		return cert, nil
-	}
-
-	return tls.Certificate{}, nil
-}
-
 func getDefaultGateway() string {
 	conn, err := net.Dial("udp", "8.8.8.8:80")
 	if err != nil {
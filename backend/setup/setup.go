@@ -18,6 +18,7 @@ type SetFlags struct {
 	DNSPort             *int
 	DoTPort             *int
 	DoHPort             *int
+	DoQPort             *int
 	WebserverPort       *int
 	LogLevel            *int
 	StatisticsRetention *int
@@ -72,6 +73,17 @@ func UpdateConfig(config *settings.Config, flags *SetFlags) {
 			config.DNS.Ports.DoH = *flags.DoHPort
 		}
 	}
+	if flags.DoQPort != nil || os.Getenv("DOQ_PORT") != "" {
+		if port, found := os.LookupEnv("DOQ_PORT"); found {
+			doqPort, err := strconv.Atoi(port)
+			if err != nil {
+				log.Fatal("Could not parse DOQ_PORT environment variable")
+			}
+			config.DNS.Ports.DoQ = doqPort
+		} else {
+			config.DNS.Ports.DoQ = *flags.DoQPort
+		}
+	}
 	if flags.WebserverPort != nil || os.Getenv("WEBSITE_PORT") != "" {
 		if port, found := os.LookupEnv("WEBSITE_PORT"); found {
 			websitePort, err := strconv.Atoi(port)
@@ -0,0 +1,214 @@
+package syslog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/logging"
+	"goaway/backend/settings"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logging.GetLogger()
+
+const (
+	SourceQueries       = "queries"
+	SourceAudit         = "audit"
+	SourceNotifications = "notifications"
+
+	FormatText = "text"
+	FormatJSON = "json"
+
+	defaultFacility = "local0"
+)
+
+// Forwarder sends query log entries, audit entries and notifications to a remote syslog server. All of its
+// methods can be called on a nil Forwarder, which forwards nothing.
+type Forwarder struct {
+	lock   sync.RWMutex
+	config settings.SyslogConfig
+	header header
+	sender *sender
+}
+
+// Validate reports whether config holds a usable syslog server.
+func Validate(config settings.SyslogConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	switch config.Network {
+	case "", NetworkUDP, NetworkTCP, NetworkTLS:
+	default:
+		return fmt.Errorf("unknown syslog network '%s', expected '%s', '%s' or '%s'", config.Network, NetworkUDP, NetworkTCP, NetworkTLS)
+	}
+	if config.Address == "" {
+		return errors.New("syslog address is required")
+	}
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return fmt.Errorf("invalid syslog address '%s', expected host:port", config.Address)
+	}
+	if _, ok := facilities[config.Facility]; !ok && config.Facility != "" {
+		return fmt.Errorf("unknown syslog facility '%s'", config.Facility)
+	}
+	switch config.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown syslog format '%s', expected '%s' or '%s'", config.Format, FormatText, FormatJSON)
+	}
+	for _, source := range config.Sources {
+		switch source {
+		case SourceQueries, SourceAudit, SourceNotifications:
+		default:
+			return fmt.Errorf("unknown syslog source '%s', expected '%s', '%s' or '%s'", source, SourceQueries, SourceAudit, SourceNotifications)
+		}
+	}
+	return nil
+}
+
+// NewForwarder creates a forwarder that sends nothing until configured by Reload.
+func NewForwarder() *Forwarder {
+	hostname, _ := os.Hostname()
+	return &Forwarder{
+		header: header{
+			hostname: headerField(hostname, maxHostnameLength),
+			procID:   strconv.Itoa(os.Getpid()),
+		},
+	}
+}
+
+// Reload applies config, only reconnecting when the server changed.
+func (f *Forwarder) Reload(config settings.SyslogConfig) error {
+	if err := Validate(config); err != nil {
+		return err
+	}
+	if config.Network == "" {
+		config.Network = NetworkUDP
+	}
+	if config.Facility == "" {
+		config.Facility = defaultFacility
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	current := f.config
+	reconnect := f.sender == nil || !config.Enabled ||
+		config.Network != current.Network || config.Address != current.Address || config.CAFile != current.CAFile
+	if f.sender != nil && reconnect {
+		f.sender.close()
+		f.sender = nil
+	}
+
+	f.config = config
+	f.header.facility = facilities[config.Facility]
+	if !config.Enabled || !reconnect {
+		return nil
+	}
+
+	sender, err := newSender(config.Network, config.Address, config.CAFile)
+	if err != nil {
+		return err
+	}
+	f.sender = sender
+	return nil
+}
+
+// Close sends the messages still queued and stops forwarding.
+func (f *Forwarder) Close() {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.sender != nil {
+		f.sender.close()
+		f.sender = nil
+	}
+}
+
+// Query forwards a query log entry.
+func (f *Forwarder) Query(entry model.RequestLogEntry) {
+	f.forward(SourceQueries, SeverityInfo, entry.Timestamp, func(format string) ([]byte, error) {
+		if format == FormatJSON {
+			return json.Marshal(entry)
+		}
+
+		var clientIP, clientName string
+		if entry.ClientInfo != nil {
+			clientIP, clientName = entry.ClientInfo.IP.String(), entry.ClientInfo.Name
+		}
+		resolved := make([]string, len(entry.IP))
+		for i, ip := range entry.IP {
+			resolved[i] = ip.IP.String()
+		}
+
+		return textMessage(nil).
+			add("client", clientIP).
+			add("name", clientName).
+			add("domain", entry.Domain).
+			add("type", entry.QueryType).
+			add("status", entry.Status).
+			add("protocol", string(entry.Protocol)).
+			add("blocked", strconv.FormatBool(entry.Blocked)).
+			add("cached", strconv.FormatBool(entry.Cached)).
+			add("response_time", entry.ResponseTime.String()).
+			add("resolved", strings.Join(resolved, ",")), nil
+	})
+}
+
+// Audit forwards an audit entry.
+func (f *Forwarder) Audit(topic, message string) {
+	f.forward(SourceAudit, SeverityNotice, time.Now(), func(format string) ([]byte, error) {
+		if format == FormatJSON {
+			return json.Marshal(map[string]string{"topic": topic, "message": message})
+		}
+		return textMessage(nil).add("topic", topic).add("message", message), nil
+	})
+}
+
+// Notification forwards a notification, severity being one of "info", "warning" and "error".
+func (f *Forwarder) Notification(severity, category, text string) {
+	level := SeverityInfo
+	switch severity {
+	case "warning":
+		level = SeverityWarning
+	case "error":
+		level = SeverityError
+	}
+
+	f.forward(SourceNotifications, level, time.Now(), func(format string) ([]byte, error) {
+		if format == FormatJSON {
+			return json.Marshal(map[string]string{"severity": severity, "category": category, "text": text})
+		}
+		return textMessage(nil).add("severity", severity).add("category", category).add("text", text), nil
+	})
+}
+
+// forward formats a message with body when source is forwarded, which is also used as its MSGID.
+func (f *Forwarder) forward(source string, severity Severity, timestamp time.Time, body func(format string) ([]byte, error)) {
+	if f == nil {
+		return
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.sender == nil || (len(f.config.Sources) > 0 && !slices.Contains(f.config.Sources, source)) {
+		return
+	}
+
+	msg, err := body(f.config.Format)
+	if err != nil {
+		log.Debug("Could not format %s syslog message: %v", source, err)
+		return
+	}
+	f.sender.send(f.header.format(severity, timestamp, source, msg))
+}
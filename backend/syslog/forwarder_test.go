@@ -0,0 +1,127 @@
+package syslog
+
+import (
+	"bufio"
+	model "goaway/backend/dns/server/models"
+	"goaway/backend/settings"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForwardOverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	// Messages are read using the octet counting framing
+	messages := make(chan string, 4)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				close(messages)
+				return
+			}
+			size, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				close(messages)
+				return
+			}
+			message := make([]byte, size)
+			if _, err := io.ReadFull(r, message); err != nil {
+				close(messages)
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+
+	forwarder := NewForwarder()
+	err = forwarder.Reload(settings.SyslogConfig{
+		Enabled:  true,
+		Network:  NetworkTCP,
+		Address:  listener.Addr().String(),
+		Facility: "local3",
+		Sources:  []string{SourceQueries, SourceNotifications},
+	})
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	forwarder.Query(model.RequestLogEntry{
+		Timestamp:    time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		ClientInfo:   &model.Client{IP: netip.MustParseAddr("192.168.1.10"), Name: "my laptop"},
+		Domain:       "example.com.",
+		Status:       "NOERROR",
+		QueryType:    "A",
+		Protocol:     model.UDP,
+		IP:           []model.ResolvedIP{{IP: netip.MustParseAddr("93.184.216.34"), RType: "A"}},
+		ResponseTime: 1500 * time.Microsecond,
+	})
+	forwarder.Audit("settings", "Settings was updated")
+	forwarder.Notification("warning", "dns", "Upstream\nunreachable")
+	forwarder.Close()
+
+	var got []string
+	for message := range messages {
+		got = append(got, message)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d messages, want 2: %q", len(got), got)
+	}
+
+	query := got[0]
+	wantPrefix := "<158>1 2026-01-02T03:04:05.000006Z "
+	if !strings.HasPrefix(query, wantPrefix) {
+		t.Errorf("query message %q, want prefix %q", query, wantPrefix)
+	}
+	wantSuffix := ` goaway ` + forwarder.header.procID + ` queries - client=192.168.1.10 name="my laptop" domain=example.com. type=A status=NOERROR protocol=UDP blocked=false cached=false response_time=1.5ms resolved=93.184.216.34`
+	if !strings.HasSuffix(query, wantSuffix) {
+		t.Errorf("query message %q, want suffix %q", query, wantSuffix)
+	}
+
+	notification := got[1]
+	if !strings.HasPrefix(notification, "<156>1 ") || !strings.HasSuffix(notification, ` notifications - severity=warning category=dns text="Upstream\nunreachable"`) {
+		t.Errorf("unexpected notification message %q", notification)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config  settings.SyslogConfig
+		wantErr bool
+	}{
+		{settings.SyslogConfig{Enabled: false, Network: "smtp"}, false},
+		{settings.SyslogConfig{Enabled: true, Address: "logs.lan:514"}, false},
+		{settings.SyslogConfig{Enabled: true, Network: NetworkTLS, Address: "logs.lan:6514", Facility: "local7", Format: FormatJSON}, false},
+		{settings.SyslogConfig{Enabled: true, Address: "logs.lan"}, true},
+		{settings.SyslogConfig{Enabled: true, Network: "smtp", Address: "logs.lan:514"}, true},
+		{settings.SyslogConfig{Enabled: true, Address: "logs.lan:514", Facility: "local8"}, true},
+		{settings.SyslogConfig{Enabled: true, Address: "logs.lan:514", Format: "xml"}, true},
+		{settings.SyslogConfig{Enabled: true, Address: "logs.lan:514", Sources: []string{"dhcp"}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) = %v, want error %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package syslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity of a syslog message, as defined by RFC 5424.
+type Severity int
+
+const (
+	SeverityError   Severity = 3
+	SeverityWarning Severity = 4
+	SeverityNotice  Severity = 5
+	SeverityInfo    Severity = 6
+)
+
+const (
+	appName = "goaway"
+
+	// Header fields are limited to printable ASCII of at most this length
+	maxHostnameLength = 255
+	maxMsgIDLength    = 32
+)
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14, "solaris-cron": 15,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// header holds the fields shared by every message sent by a forwarder.
+type header struct {
+	facility int
+	hostname string
+	procID   string
+}
+
+// format returns an RFC 5424 message without structured data.
+func (h header) format(severity Severity, timestamp time.Time, msgID string, msg []byte) []byte {
+	b := make([]byte, 0, 96+len(msg))
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(h.facility*8+int(severity)), 10)
+	b = append(b, ">1 "...)
+	b = timestamp.UTC().AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	b = append(b, ' ')
+	b = append(b, h.hostname...)
+	b = append(b, ' ')
+	b = append(b, appName...)
+	b = append(b, ' ')
+	b = append(b, h.procID...)
+	b = append(b, ' ')
+	b = append(b, headerField(msgID, maxMsgIDLength)...)
+	b = append(b, " - "...)
+	return append(b, msg...)
+}
+
+// headerField replaces characters not allowed in a header field, which is "-" when empty.
+func headerField(value string, maxLength int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < '!' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// textMessage builds a message of key=value pairs, quoting values that would otherwise be ambiguous.
+type textMessage []byte
+
+func (m textMessage) add(key, value string) textMessage {
+	if len(m) > 0 {
+		m = append(m, ' ')
+	}
+	m = append(m, key...)
+	m = append(m, '=')
+	if value == "" || strings.ContainsAny(value, " \"=\\") || strconv.Quote(value) != `"`+value+`"` {
+		return strconv.AppendQuote(m, value)
+	}
+	return append(m, value...)
+}
@@ -0,0 +1,156 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	NetworkUDP = "udp"
+	NetworkTCP = "tcp"
+	NetworkTLS = "tls"
+
+	queueSize  = 10000
+	ioTimeout  = 5 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// sender writes messages to a syslog server from a queue, reconnecting when the connection fails. Messages
+// are dropped when the server can't keep up, so that logging never holds up a query.
+type sender struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+
+	queue   chan []byte
+	dropped atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newSender(network, address, caFile string) (*sender, error) {
+	s := &sender{
+		network: network,
+		address: address,
+		queue:   make(chan []byte, queueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if network == NetworkTLS {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read syslog CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in syslog CA file %s", caFile)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	}
+
+	go s.run()
+	return s, nil
+}
+
+func (s *sender) send(message []byte) {
+	select {
+	case s.queue <- message:
+	default:
+		if s.dropped.Add(1) == 1 {
+			log.Warning("Syslog server %s can't keep up, dropping messages", s.address)
+		}
+	}
+}
+
+// close writes the queued messages and closes the connection.
+func (s *sender) close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *sender) run() {
+	defer close(s.done)
+
+	backoff := time.Second
+	for {
+		conn, err := s.dial()
+		if err == nil {
+			backoff = time.Second
+			log.Info("Forwarding logs to syslog server %s over %s", s.address, s.network)
+			stopped, writeErr := s.write(conn)
+			_ = conn.Close()
+			if stopped {
+				return
+			}
+			err = writeErr
+		}
+
+		log.Warning("Syslog server %s failed, retrying in %s: %v", s.address, backoff, err)
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+func (s *sender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: ioTimeout}
+	if s.network == NetworkTLS {
+		return tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	}
+	return dialer.Dial(s.network, s.address)
+}
+
+// write sends queued messages over conn until the sender is closed, which is reported by stopped.
+func (s *sender) write(conn net.Conn) (stopped bool, err error) {
+	for {
+		select {
+		case message := <-s.queue:
+			if err := s.writeMessage(conn, message); err != nil {
+				return false, err
+			}
+		case <-s.stop:
+			for len(s.queue) > 0 {
+				if err := s.writeMessage(conn, <-s.queue); err != nil {
+					log.Debug("Syslog server %s did not receive every message: %v", s.address, err)
+					break
+				}
+			}
+			return true, nil
+		}
+	}
+}
+
+// writeMessage sends a datagram per message over UDP, while streams use octet counting (RFC 6587), as
+// messages may contain newlines.
+func (s *sender) writeMessage(conn net.Conn, message []byte) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+	if s.network != NetworkUDP {
+		framed := strconv.AppendInt(make([]byte, 0, len(message)+8), int64(len(message)), 10)
+		framed = append(framed, ' ')
+		message = append(framed, message...)
+	}
+	_, err := conn.Write(message)
+	return err
+}
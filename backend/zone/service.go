@@ -0,0 +1,207 @@
+package zone
+
+import (
+	"errors"
+	"fmt"
+	"goaway/backend/logging"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"codeberg.org/miekg/dns/dnsutil"
+)
+
+const (
+	DefaultDirectory = "./config/zones"
+	fileExtension    = ".zone"
+)
+
+var log = logging.GetLogger()
+
+// Info summarizes a loaded zone.
+type Info struct {
+	Origin  string `json:"origin"`
+	Serial  uint32 `json:"serial"`
+	Records int    `json:"records"`
+}
+
+// Service keeps the zones found in a directory, one file per zone named after its origin, e.g. "home.lan.zone".
+type Service struct {
+	directory string
+
+	mu    sync.RWMutex
+	zones map[string]*Zone
+}
+
+func NewService(directory string) *Service {
+	service := &Service{
+		directory: directory,
+		zones:     map[string]*Zone{},
+	}
+
+	if err := service.Reload(); err != nil {
+		log.Warning("Could not load zones, %v", err)
+	}
+
+	return service
+}
+
+// Reload reads every zone file from disk. Zones that fail to parse are skipped and reported in the returned error.
+func (s *Service) Reload() error {
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read zone directory: %w", err)
+	}
+
+	zones := make(map[string]*Zone)
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) {
+			continue
+		}
+
+		origin := strings.TrimSuffix(entry.Name(), fileExtension)
+		zone, err := s.readZone(origin)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("zone %s: %w", origin, err))
+			continue
+		}
+		zones[zone.Origin] = zone
+	}
+
+	s.mu.Lock()
+	s.zones = zones
+	s.mu.Unlock()
+
+	if len(zones) > 0 {
+		log.Info("Loaded %d authoritative zones", len(zones))
+	}
+	return errors.Join(errs...)
+}
+
+// Find returns the most specific zone qname belongs to.
+func (s *Service) Find(qname string) (*Zone, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.zones) == 0 {
+		return nil, false
+	}
+
+	for name := strings.ToLower(dnsutil.Fqdn(qname)); name != "."; name = parent(name) {
+		if zone, found := s.zones[name]; found {
+			return zone, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Service) List() []Info {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	zones := make([]Info, 0, len(s.zones))
+	for _, zone := range s.zones {
+		zones = append(zones, Info{
+			Origin:  zone.Origin,
+			Serial:  zone.SOA.Serial,
+			Records: zone.RecordCount(),
+		})
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Origin < zones[j].Origin })
+
+	return zones
+}
+
+// GetContent returns the zone file as stored on disk.
+func (s *Service) GetContent(origin string) (string, error) {
+	path, err := s.path(origin)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("zone %s does not exist", origin)
+		}
+		return "", fmt.Errorf("failed to read zone: %w", err)
+	}
+	return string(content), nil
+}
+
+// Save validates and stores the zone file, replacing the zone currently served for origin.
+func (s *Service) Save(origin, content string) (Info, error) {
+	path, err := s.path(origin)
+	if err != nil {
+		return Info{}, err
+	}
+
+	zone, err := Parse(strings.NewReader(content), origin, path)
+	if err != nil {
+		return Info{}, fmt.Errorf("invalid zone: %w", err)
+	}
+
+	if err := os.MkdirAll(s.directory, 0755); err != nil {
+		return Info{}, fmt.Errorf("failed to create zone directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return Info{}, fmt.Errorf("failed to save zone: %w", err)
+	}
+
+	s.mu.Lock()
+	s.zones[zone.Origin] = zone
+	s.mu.Unlock()
+
+	return Info{Origin: zone.Origin, Serial: zone.SOA.Serial, Records: zone.RecordCount()}, nil
+}
+
+func (s *Service) Delete(origin string) error {
+	path, err := s.path(origin)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("zone %s does not exist", origin)
+		}
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+
+	s.mu.Lock()
+	delete(s.zones, strings.ToLower(dnsutil.Fqdn(origin)))
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) readZone(origin string) (*Zone, error) {
+	path, err := s.path(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return Parse(file, origin, path)
+}
+
+// path returns the file for origin, rejecting anything that is not a plain domain name.
+func (s *Service) path(origin string) (string, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), ".")
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") || !dnsutil.IsName(dnsutil.Fqdn(name)) {
+		return "", fmt.Errorf("invalid zone name '%s'", origin)
+	}
+	return filepath.Join(s.directory, name+fileExtension), nil
+}
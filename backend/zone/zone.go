@@ -0,0 +1,189 @@
+package zone
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+)
+
+// Number of CNAMEs within a zone followed to answer a query
+const maxCNAMEChain = 8
+
+// Zone is a parsed RFC 1035 zone which GoAway is authoritative for.
+type Zone struct {
+	Origin string
+	SOA    *dns.SOA
+	// Records keyed by lowercase owner name and type
+	records map[string]map[uint16][]dns.RR
+	count   int
+}
+
+// Answer is the authoritative response for a query within a zone.
+type Answer struct {
+	Rcode         uint16
+	Authoritative bool
+	Answer        []dns.RR
+	Ns            []dns.RR
+}
+
+// Parse reads a zone file for origin. $INCLUDE is not allowed, as zone files can be edited through the API.
+func Parse(r io.Reader, origin, file string) (*Zone, error) {
+	origin = strings.ToLower(dnsutil.Fqdn(origin))
+	zone := &Zone{
+		Origin:  origin,
+		records: map[string]map[uint16][]dns.RR{},
+	}
+
+	parser := dns.NewZoneParser(r, origin, file)
+	parser.IncludeAllowFunc = func(_, _ string) bool { return false }
+
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		name := strings.ToLower(rr.Header().Name)
+		if !dnsutil.IsBelow(origin, name) {
+			return nil, fmt.Errorf("%s is outside of zone %s", rr.Header().Name, origin)
+		}
+
+		rrType := dns.RRToType(rr)
+		if soa, ok := rr.(*dns.SOA); ok {
+			if name != origin {
+				return nil, fmt.Errorf("SOA record must be at the zone apex %s, found at %s", origin, name)
+			}
+			if zone.SOA != nil {
+				return nil, fmt.Errorf("zone %s has more than one SOA record", origin)
+			}
+			zone.SOA = soa
+		}
+
+		if zone.records[name] == nil {
+			zone.records[name] = map[uint16][]dns.RR{}
+		}
+		zone.records[name][rrType] = append(zone.records[name][rrType], rr)
+		zone.count++
+	}
+	if err := parser.Err(); err != nil {
+		return nil, err
+	}
+
+	if zone.SOA == nil {
+		return nil, fmt.Errorf("zone %s has no SOA record", origin)
+	}
+
+	for name, types := range zone.records {
+		if _, hasCNAME := types[dns.TypeCNAME]; hasCNAME && len(types) > 1 {
+			return nil, fmt.Errorf("%s has a CNAME combined with other records", name)
+		}
+	}
+
+	return zone, nil
+}
+
+func (z *Zone) RecordCount() int {
+	return z.count
+}
+
+// Lookup answers qname and qtype from the zone. qname must be within the zone.
+func (z *Zone) Lookup(qname string, qtype uint16) Answer {
+	return z.lookup(qname, qtype, 0)
+}
+
+// lookup answers qname, having followed depth CNAMEs within the zone to get there. The chain is cut short
+// after maxCNAMEChain, so that a CNAME loop in a zone file can't exhaust the stack.
+func (z *Zone) lookup(qname string, qtype uint16, depth int) Answer {
+	name := strings.ToLower(dnsutil.Fqdn(qname))
+
+	if referral, found := z.findDelegation(name); found {
+		return Answer{Rcode: dns.RcodeSuccess, Ns: referral}
+	}
+
+	types, found := z.records[name]
+	if !found && !z.isEmptyNonTerminal(name) {
+		types, found = z.findWildcard(name)
+		if !found {
+			return Answer{Rcode: dns.RcodeNameError, Authoritative: true, Ns: z.negativeSOA()}
+		}
+	}
+
+	if rrs, ok := types[qtype]; ok {
+		return Answer{Rcode: dns.RcodeSuccess, Authoritative: true, Answer: withOwner(rrs, qname)}
+	}
+
+	if qtype != dns.TypeCNAME {
+		if cnames, ok := types[dns.TypeCNAME]; ok {
+			answer := withOwner(cnames, qname)
+			target := strings.ToLower(cnames[0].(*dns.CNAME).Target)
+			if target != name && depth < maxCNAMEChain && dnsutil.IsBelow(z.Origin, target) {
+				chased := z.lookup(target, qtype, depth+1)
+				answer = append(answer, chased.Answer...)
+				return Answer{Rcode: chased.Rcode, Authoritative: true, Answer: answer, Ns: chased.Ns}
+			}
+			return Answer{Rcode: dns.RcodeSuccess, Authoritative: true, Answer: answer}
+		}
+	}
+
+	// The name exists, but not with the requested type
+	return Answer{Rcode: dns.RcodeSuccess, Authoritative: true, Ns: z.negativeSOA()}
+}
+
+// findDelegation returns the NS records of a delegated child zone above or at name.
+func (z *Zone) findDelegation(name string) ([]dns.RR, bool) {
+	for current := name; current != z.Origin && dnsutil.IsBelow(z.Origin, current); current = parent(current) {
+		if ns, ok := z.records[current][dns.TypeNS]; ok {
+			return ns, true
+		}
+	}
+	return nil, false
+}
+
+// isEmptyNonTerminal reports whether name has no records itself but names below it do, e.g. "_tcp.home.lan."
+// when only "_sip._tcp.home.lan." exists. Such names must answer NODATA rather than NXDOMAIN.
+func (z *Zone) isEmptyNonTerminal(name string) bool {
+	suffix := "." + name
+	for owner := range z.records {
+		if strings.HasSuffix(owner, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findWildcard looks for "*.<closest encloser>" as described in RFC 4592.
+func (z *Zone) findWildcard(name string) (map[uint16][]dns.RR, bool) {
+	for encloser := parent(name); dnsutil.IsBelow(z.Origin, encloser); encloser = parent(encloser) {
+		if _, exists := z.records[encloser]; exists || z.isEmptyNonTerminal(encloser) {
+			types, found := z.records["*."+encloser]
+			return types, found
+		}
+		if encloser == z.Origin {
+			break
+		}
+	}
+	return nil, false
+}
+
+// negativeSOA is the SOA placed in the authority section of NXDOMAIN and NODATA answers, its TTL
+// is the negative caching TTL from RFC 2308.
+func (z *Zone) negativeSOA() []dns.RR {
+	soa := z.SOA.Clone()
+	soa.Header().TTL = min(z.SOA.Header().TTL, z.SOA.Minttl)
+	return []dns.RR{soa}
+}
+
+func withOwner(rrs []dns.RR, owner string) []dns.RR {
+	answers := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		answer := rr.Clone()
+		answer.Header().Name = dnsutil.Fqdn(owner)
+		answers = append(answers, answer)
+	}
+	return answers
+}
+
+func parent(name string) string {
+	if idx := strings.IndexByte(name, '.'); idx != -1 && idx < len(name)-1 {
+		return name[idx+1:]
+	}
+	return "."
+}
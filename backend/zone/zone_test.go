@@ -0,0 +1,101 @@
+package zone
+
+import (
+	"strings"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+)
+
+const homeZone = `$TTL 3600
+@        IN SOA  ns.home.lan. admin.home.lan. 2024010101 7200 3600 1209600 300
+@        IN NS   ns.home.lan.
+ns       IN A    192.168.1.1
+nas      IN A    192.168.1.10
+nas      IN AAAA fd00::10
+files    IN CNAME nas
+*.apps   IN A    192.168.1.20
+_sip._tcp IN SRV 10 5 5060 nas.home.lan.
+lab      IN NS   ns.lab.home.lan.
+`
+
+func TestLookup(t *testing.T) {
+	zone, err := Parse(strings.NewReader(homeZone), "home.lan", "home.lan.zone")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		qname         string
+		qtype         uint16
+		rcode         uint16
+		authoritative bool
+		answers       int
+		ns            int
+	}{
+		{"existing record", "nas.home.lan.", dns.TypeA, dns.RcodeSuccess, true, 1, 0},
+		{"case insensitive", "NAS.Home.Lan.", dns.TypeAAAA, dns.RcodeSuccess, true, 1, 0},
+		{"nodata", "nas.home.lan.", dns.TypeMX, dns.RcodeSuccess, true, 0, 1},
+		{"nxdomain", "missing.home.lan.", dns.TypeA, dns.RcodeNameError, true, 0, 1},
+		{"cname followed within zone", "files.home.lan.", dns.TypeA, dns.RcodeSuccess, true, 2, 0},
+		{"wildcard", "grafana.apps.home.lan.", dns.TypeA, dns.RcodeSuccess, true, 1, 0},
+		{"empty non-terminal", "_tcp.home.lan.", dns.TypeA, dns.RcodeSuccess, true, 0, 1},
+		{"apex soa", "home.lan.", dns.TypeSOA, dns.RcodeSuccess, true, 1, 0},
+		{"delegation", "host.lab.home.lan.", dns.TypeA, dns.RcodeSuccess, false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := zone.Lookup(tt.qname, tt.qtype)
+			if answer.Rcode != tt.rcode || answer.Authoritative != tt.authoritative ||
+				len(answer.Answer) != tt.answers || len(answer.Ns) != tt.ns {
+				t.Errorf("Lookup(%s, %s) = rcode %d, aa %v, %d answers, %d ns; want rcode %d, aa %v, %d answers, %d ns",
+					tt.qname, dns.TypeToString[tt.qtype], answer.Rcode, answer.Authoritative, len(answer.Answer), len(answer.Ns),
+					tt.rcode, tt.authoritative, tt.answers, tt.ns)
+			}
+			for _, rr := range answer.Answer[:min(1, len(answer.Answer))] {
+				if rr.Header().Name != tt.qname {
+					t.Errorf("answer owner = %s, want %s", rr.Header().Name, tt.qname)
+				}
+			}
+		})
+	}
+
+	if soa := zone.Lookup("missing.home.lan.", dns.TypeA).Ns[0]; soa.Header().TTL != 300 {
+		t.Errorf("negative SOA TTL = %d, want 300", soa.Header().TTL)
+	}
+}
+
+func TestLookupCNAMELoop(t *testing.T) {
+	const loopZone = `$TTL 3600
+@ IN SOA ns.home.lan. admin.home.lan. 1 7200 3600 1209600 300
+a IN CNAME b
+b IN CNAME a
+`
+	zone, err := Parse(strings.NewReader(loopZone), "home.lan", "home.lan.zone")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	answer := zone.Lookup("a.home.lan.", dns.TypeA)
+	if answer.Rcode != dns.RcodeSuccess || len(answer.Answer) != maxCNAMEChain+1 {
+		t.Errorf("Lookup(a.home.lan.) = rcode %d, %d answers; want rcode %d, %d answers",
+			answer.Rcode, len(answer.Answer), dns.RcodeSuccess, maxCNAMEChain+1)
+	}
+}
+
+func TestParseRejectsInvalidZones(t *testing.T) {
+	tests := map[string]string{
+		"missing soa":    "@ IN A 192.168.1.1\n",
+		"outside origin": "@ IN SOA ns.home.lan. admin.home.lan. 1 7200 3600 1209600 300\nexample.com. IN A 192.168.1.1\n",
+		"include":        "@ IN SOA ns.home.lan. admin.home.lan. 1 7200 3600 1209600 300\n$INCLUDE /etc/passwd\n",
+		"cname conflict": "@ IN SOA ns.home.lan. admin.home.lan. 1 7200 3600 1209600 300\nx IN CNAME y\nx IN A 192.168.1.1\n",
+	}
+
+	for name, content := range tests {
+		if _, err := Parse(strings.NewReader(content), "home.lan", "home.lan.zone"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
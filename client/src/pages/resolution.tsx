@@ -24,27 +24,26 @@ import { toast } from "sonner";
 import { validateFQDN } from "./validation";
 
 type ResolutionT = {
-  resolution: {
-    [hostname: string]: string;
-  };
+  id: number;
+  domain: string;
+  type: string;
+  value: string;
+  ttl: number;
 };
 
 async function CreateResolution(domain: string, ip: string) {
   const [code, response] = await PostRequest("resolution", { ip, domain });
   if (code === 200) {
     toast.success(`${domain} has been added!`);
-    return true;
+    return response as ResolutionT;
   } else {
     toast.error(response.error);
-    return false;
+    return null;
   }
 }
 
-async function DeleteResolution(domain: string, ip: string) {
-  const [code, response] = await DeleteRequest(
-    `resolution?domain=${domain}&ip=${ip}`,
-    null
-  );
+async function DeleteResolution(id: number, domain: string) {
+  const [code, response] = await DeleteRequest(`resolution?id=${id}`, null);
   if (code === 200) {
     toast.success(`${domain} was deleted!`);
     return true;
@@ -55,7 +54,7 @@ async function DeleteResolution(domain: string, ip: string) {
 }
 
 export function Resolution() {
-  const [resolutions, setResolutions] = useState<ResolutionT["resolution"]>({});
+  const [resolutions, setResolutions] = useState<ResolutionT[]>([]);
   const [loading, setLoading] = useState(true);
   const [submitting, setSubmitting] = useState(false);
   const [domainName, setDomainName] = useState("");
@@ -73,7 +72,7 @@ export function Resolution() {
         return;
       }
 
-      setResolutions((response as ResolutionT["resolution"]) || {});
+      setResolutions((response as ResolutionT[]) || []);
       setLoading(false);
     })();
   }, []);
@@ -102,9 +101,9 @@ export function Resolution() {
     }
 
     setSubmitting(true);
-    const success = await CreateResolution(domainName, ip);
-    if (success) {
-      setResolutions((prev) => ({ ...prev, [domainName]: ip }));
+    const created = await CreateResolution(domainName, ip);
+    if (created) {
+      setResolutions((prev) => [...prev, created]);
       setDomainName("");
       setIP("");
       setDomainError(undefined);
@@ -112,29 +111,20 @@ export function Resolution() {
     setSubmitting(false);
   };
 
-  const handleDelete = async (domain: string, ip: string) => {
-    const success = await DeleteResolution(domain, ip);
+  const handleDelete = async (resolution: ResolutionT) => {
+    const success = await DeleteResolution(resolution.id, resolution.domain);
     if (success) {
-      setResolutions((prev) => {
-        const next = { ...prev };
-        delete next[domain];
-        return next;
-      });
+      setResolutions((prev) => prev.filter((r) => r.id !== resolution.id));
     }
   };
 
-  const entries = Object.entries(resolutions).map(([domain, ip]) => ({
-    domain,
-    ip
-  }));
-
   const filteredResolutions = searchTerm
-    ? entries.filter(
+    ? resolutions.filter(
         (res) =>
           res.domain.toLowerCase().includes(searchTerm.toLowerCase()) ||
-          res.ip.includes(searchTerm)
+          res.value.includes(searchTerm)
       )
-    : entries;
+    : resolutions;
 
   const isFormValid = domainName && ip && !domainError;
 
@@ -318,7 +308,7 @@ export function Resolution() {
             <div className="divide-y divide-stone">
               {filteredResolutions.map((resolution) => (
                 <div
-                  key={`${resolution.domain}-${resolution.ip}`}
+                  key={resolution.id}
                   className="group flex items-center justify-between p-2 hover:bg-accent transition-all duration-200"
                 >
                   <div className="flex items-center gap-4 flex-1">
@@ -339,8 +329,11 @@ export function Resolution() {
                       </div>
                       <div className="flex items-center gap-2 text-sm text-muted-foreground">
                         <NetworkIcon />
+                        <span className="font-mono text-xs">
+                          {resolution.type}
+                        </span>
                         <code className="font-mono bg-accent px-2 py-0.5 rounded">
-                          {resolution.ip}
+                          {resolution.value}
                         </code>
                       </div>
                     </div>
@@ -350,9 +343,7 @@ export function Resolution() {
                       variant="ghost"
                       size="sm"
                       className="h-8 w-8 p-0 text-red-400 hover:text-red-300 hover:bg-red-500/10 cursor-pointer"
-                      onClick={() =>
-                        handleDelete(resolution.domain, resolution.ip)
-                      }
+                      onClick={() => handleDelete(resolution)}
                     >
                       <TrashIcon className="h-4 w-4" />
                       <span className="sr-only">Delete</span>
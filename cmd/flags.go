@@ -10,6 +10,7 @@ type Flags struct {
 	DnsPort             int
 	DoTPort             int
 	DoHPort             int
+	DoQPort             int
 	WebserverPort       int
 	LogLevel            int
 	StatisticsRetention int
@@ -29,6 +30,7 @@ func (f *Flags) Register(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&f.DnsPort, "dns-port", 53, "Port for the DNS server")
 	cmd.Flags().IntVar(&f.DoTPort, "dot-port", 853, "Port for the DoT (DNS-over-TCP) server")
 	cmd.Flags().IntVar(&f.DoHPort, "doh-port", 443, "Port for the DoH (DNS-over-HTTPS) server")
+	cmd.Flags().IntVar(&f.DoQPort, "doq-port", 853, "Port for the DoQ (DNS-over-QUIC) server, 0 to disable")
 	cmd.Flags().IntVar(&f.WebserverPort, "webserver-port", 8080, "Port for the web server")
 	cmd.Flags().IntVar(&f.LogLevel, "log-level", 1, "0 = DEBUG | 1 = INFO | 2 = WARNING | 3 = ERROR")
 	cmd.Flags().IntVar(&f.StatisticsRetention, "statistics-retention", 7, "Days to keep statistics")
@@ -52,6 +54,9 @@ func (f *Flags) GetSetFlags(cmd *cobra.Command) *setup.SetFlags {
 	if cmd.Flags().Changed("doh-port") {
 		setFlags.DoHPort = &f.DoHPort
 	}
+	if cmd.Flags().Changed("doq-port") {
+		setFlags.DoQPort = &f.DoQPort
+	}
 	if cmd.Flags().Changed("webserver-port") {
 		setFlags.WebserverPort = &f.WebserverPort
 	}